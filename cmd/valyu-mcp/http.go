@@ -0,0 +1,187 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	sessionHeader = "Mcp-Session-Id"
+	sessionIdle   = time.Hour
+
+	// defaultMaxSessions bounds the sessions an HTTP server keeps.
+	defaultMaxSessions = 64
+)
+
+// httpHandler implements the MCP streamable HTTP transport on a single
+// endpoint. Each initialize request starts a session whose ID the client
+// sends back in the Mcp-Session-Id header; once maxSessions are open, new
+// ones are refused with 503 until a session ends or goes idle. Replies are
+// plain JSON; the server never opens an event stream of its own.
+type httpHandler struct {
+	srv            *server
+	allowedOrigins []string
+	maxSessions    int
+
+	mu       sync.Mutex
+	sessions map[string]*httpSession
+}
+
+type httpSession struct {
+	*session
+	lastUsed time.Time
+}
+
+func newHTTPHandler(srv *server, allowedOrigins []string, maxSessions int) *httpHandler {
+	if maxSessions <= 0 {
+		maxSessions = defaultMaxSessions
+	}
+	return &httpHandler{
+		srv:            srv,
+		allowedOrigins: allowedOrigins,
+		maxSessions:    maxSessions,
+		sessions:       map[string]*httpSession{},
+	}
+}
+
+func (h *httpHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Browsers on other sites must not reach a server bound to localhost.
+	if !h.originAllowed(r.Header.Get("Origin")) {
+		http.Error(w, "origin not allowed", http.StatusForbidden)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+		h.post(w, r)
+	case http.MethodDelete:
+		id := r.Header.Get(sessionHeader)
+		h.mu.Lock()
+		_, ok := h.sessions[id]
+		delete(h.sessions, id)
+		h.mu.Unlock()
+		if !ok {
+			http.Error(w, "unknown session", http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.Header().Set("Allow", "POST, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *httpHandler) post(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxMessageSize+1))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(body) > maxMessageSize {
+		http.Error(w, "message too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	var sess *session
+	if isInitialize(body) {
+		if sess = h.newSession(); sess == nil {
+			w.Header().Set("Retry-After", "60")
+			http.Error(w, "too many sessions", http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set(sessionHeader, sess.id)
+	} else {
+		id := r.Header.Get(sessionHeader)
+		if id == "" {
+			http.Error(w, "missing "+sessionHeader+" header", http.StatusBadRequest)
+			return
+		}
+		if sess = h.session(id); sess == nil {
+			http.Error(w, "unknown session", http.StatusNotFound)
+			return
+		}
+	}
+
+	reply := sess.handle(r.Context(), body)
+	if reply == nil {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(reply)
+}
+
+// newSession starts a session, or returns nil if maxSessions are open.
+func (h *httpHandler) newSession() *session {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	now := time.Now()
+	for k, s := range h.sessions {
+		if now.Sub(s.lastUsed) > sessionIdle {
+			delete(h.sessions, k)
+		}
+	}
+	if len(h.sessions) >= h.maxSessions {
+		return nil
+	}
+
+	id := newSessionID()
+	sess := h.srv.newSession(id)
+	h.sessions[id] = &httpSession{session: sess, lastUsed: now}
+	return sess
+}
+
+func (h *httpHandler) session(id string) *session {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s, ok := h.sessions[id]
+	if !ok {
+		return nil
+	}
+	s.lastUsed = time.Now()
+	return s.session
+}
+
+func (h *httpHandler) originAllowed(origin string) bool {
+	if origin == "" {
+		return true
+	}
+	for _, o := range h.allowedOrigins {
+		if strings.EqualFold(o, origin) {
+			return true
+		}
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	host := u.Hostname()
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func isInitialize(body []byte) bool {
+	var msg struct {
+		Method string `json:"method"`
+	}
+	return json.Unmarshal(body, &msg) == nil && msg.Method == "initialize"
+}
+
+func newSessionID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,161 @@
+// Command valyu-mcp is a Model Context Protocol server that gives AI
+// assistants access to Valyu search, contents, answers, deep research and
+// the datasource catalogue.
+//
+// It speaks MCP over stdio by default:
+//
+//	VALYU_API_KEY=... valyu-mcp
+//
+// or over streamable HTTP:
+//
+//	VALYU_API_KEY=... valyu-mcp -transport http -addr 127.0.0.1:8765
+//
+// Searches can be limited to an allowlist of sources with -allowed-sources.
+// -max-session-spend caps the dollars each MCP session may spend and
+// -max-spend caps the server's total across sessions. -max-sessions bounds
+// the sessions the http transport keeps open. Every flag can also be set
+// through the environment variable named in its usage text.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/Veri5ied/valyu-go/valyu"
+	"github.com/Veri5ied/valyu-go/valyu/tools"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := run(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr); err != nil {
+		fmt.Fprintln(os.Stderr, "valyu-mcp:", err)
+		os.Exit(1)
+	}
+}
+
+// run is main without the process globals, so the server can be driven in
+// process, e.g. against a fake Valyu API given with -base-url.
+func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("valyu-mcp", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	var (
+		transport       = fs.String("transport", env("VALYU_MCP_TRANSPORT", "stdio"), "transport to serve: stdio or http (VALYU_MCP_TRANSPORT)")
+		addr            = fs.String("addr", env("VALYU_MCP_ADDR", "127.0.0.1:8765"), "listen address for the http transport (VALYU_MCP_ADDR)")
+		apiKey          = fs.String("api-key", "", "Valyu API key (VALYU_API_KEY)")
+		baseURL         = fs.String("base-url", env("VALYU_BASE_URL", valyu.DefaultBaseURL), "Valyu API base URL (VALYU_BASE_URL)")
+		allowedSources  = fs.String("allowed-sources", env("VALYU_MCP_ALLOWED_SOURCES", ""), "comma-separated domains and dataset IDs that tools may use (VALYU_MCP_ALLOWED_SOURCES)")
+		allowedOrigins  = fs.String("allowed-origins", env("VALYU_MCP_ALLOWED_ORIGINS", ""), "comma-separated browser origins accepted by the http transport besides localhost (VALYU_MCP_ALLOWED_ORIGINS)")
+		maxSpend        = fs.Float64("max-spend", envFloat("VALYU_MCP_MAX_SPEND", 0), "dollars the server may spend across all sessions, 0 for no limit (VALYU_MCP_MAX_SPEND)")
+		maxSessionSpend = fs.Float64("max-session-spend", envFloat("VALYU_MCP_MAX_SESSION_SPEND", 0), "dollars each session may spend, 0 for no limit (VALYU_MCP_MAX_SESSION_SPEND)")
+		maxSessions     = fs.Int("max-sessions", int(envFloat("VALYU_MCP_MAX_SESSIONS", defaultMaxSessions)), "sessions the http transport keeps open at once (VALYU_MCP_MAX_SESSIONS)")
+		maxTokens       = fs.Int("max-tokens", int(envFloat("VALYU_MCP_MAX_TOKENS", tools.DefaultMaxTokens)), "approximate token budget of each tool result (VALYU_MCP_MAX_TOKENS)")
+		verbose         = fs.Bool("v", false, "log requests to stderr")
+	)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	level := slog.LevelInfo
+	if *verbose {
+		level = slog.LevelDebug
+	}
+	logger := slog.New(slog.NewTextHandler(stderr, &slog.HandlerOptions{Level: level}))
+
+	opts := []valyu.Option{
+		valyu.WithBaseURL(*baseURL),
+		valyu.WithUserAgent("valyu-mcp"),
+		valyu.WithRetry(valyu.DefaultRetryPolicy),
+	}
+	if *verbose {
+		opts = append(opts, valyu.WithLogger(logger), valyu.WithLogLevel(slog.LevelDebug))
+	}
+	client, err := valyu.New(*apiKey, opts...)
+	if err != nil {
+		return err
+	}
+
+	srv := &server{
+		client:          client,
+		logger:          logger,
+		allowedSources:  splitList(*allowedSources),
+		maxTokens:       *maxTokens,
+		maxSessionSpend: *maxSessionSpend,
+	}
+	if *maxSpend > 0 {
+		srv.budget = tools.NewBudget(*maxSpend)
+	}
+
+	switch *transport {
+	case "stdio":
+		return serveStdio(ctx, srv, stdin, stdout)
+	case "http":
+		ln, err := net.Listen("tcp", *addr)
+		if err != nil {
+			return err
+		}
+		logger.Info("serving MCP over HTTP", "url", "http://"+ln.Addr().String()+"/mcp")
+		return serveHTTP(ctx, ln, newHTTPHandler(srv, splitList(*allowedOrigins), *maxSessions))
+	}
+	return fmt.Errorf("unknown transport %q", *transport)
+}
+
+func serveHTTP(ctx context.Context, ln net.Listener, h http.Handler) error {
+	mux := http.NewServeMux()
+	mux.Handle("/mcp", h)
+	hs := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	errc := make(chan error, 1)
+	go func() { errc <- hs.Serve(ln) }()
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := hs.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-errc; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func env(name, def string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return def
+}
+
+func envFloat(name string, def float64) float64 {
+	if v, err := strconv.ParseFloat(os.Getenv(name), 64); err == nil {
+		return v
+	}
+	return def
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeValyu serves /search, charging 0.3 dollars a call.
+type fakeValyu struct {
+	mu    sync.Mutex
+	calls int
+}
+
+func (f *fakeValyu) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/search" {
+		http.NotFound(w, r)
+		return
+	}
+	f.mu.Lock()
+	f.calls++
+	f.mu.Unlock()
+	fmt.Fprint(w, `{"success":true,"query":"q","results":[{"title":"Attention","url":"https://arxiv.org/abs/1706.03762","content":"text","source":"arxiv.org"}],"total_deduction_dollars":0.3}`)
+}
+
+func (f *fakeValyu) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls
+}
+
+func newFakeValyu(t *testing.T) (*fakeValyu, string) {
+	t.Helper()
+	f := &fakeValyu{}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	return f, srv.URL
+}
+
+// syncBuffer is a bytes.Buffer safe to read while run logs to it.
+type syncBuffer struct {
+	mu sync.Mutex
+	b  bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.b.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.b.String()
+}
+
+type rpcReply struct {
+	ID     int `json:"id"`
+	Result struct {
+		ProtocolVersion string `json:"protocolVersion"`
+		Instructions    string `json:"instructions"`
+		Tools           []struct {
+			Name string `json:"name"`
+		} `json:"tools"`
+		Content []struct {
+			Text string `json:"text"`
+		} `json:"content"`
+		IsError bool `json:"isError"`
+	} `json:"result"`
+	Error *rpcError `json:"error"`
+}
+
+func (r rpcReply) text() string {
+	if len(r.Result.Content) == 0 {
+		return ""
+	}
+	return r.Result.Content[0].Text
+}
+
+func rpc(id int, method, params string) string {
+	return fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"method":%q,"params":%s}`, id, method, params)
+}
+
+func searchCall(id int, args string) string {
+	return rpc(id, "tools/call", `{"name":"valyu_search","arguments":`+args+`}`)
+}
+
+// checkSession runs the exchange shared by both transports: initialize,
+// tools/list, an allowed search, a search outside the allowlist, and
+// searches until the session's spend cap stops them. send delivers one message and
+// returns the reply.
+func checkSession(t *testing.T, f *fakeValyu, send func(msg string) rpcReply) {
+	t.Helper()
+	init := send(rpc(1, "initialize", `{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"test","version":"0"}}`))
+	if init.Error != nil || init.Result.ProtocolVersion != "2025-03-26" {
+		t.Fatalf("initialize = %+v", init)
+	}
+	if !strings.Contains(init.Result.Instructions, "arxiv.org") || !strings.Contains(init.Result.Instructions, "$0.50") {
+		t.Errorf("instructions = %q, want the allowlist and session spend cap", init.Result.Instructions)
+	}
+
+	list := send(rpc(2, "tools/list", `{}`))
+	names := map[string]bool{}
+	for _, tool := range list.Result.Tools {
+		names[tool.Name] = true
+	}
+	if !names["valyu_search"] || !names["valyu_answer"] {
+		t.Errorf("tools/list = %+v", list.Result.Tools)
+	}
+
+	res := send(searchCall(3, `{"query":"transformers"}`))
+	if res.Result.IsError || !strings.Contains(res.text(), "Attention") {
+		t.Fatalf("search = %+v", res)
+	}
+
+	res = send(searchCall(4, `{"query":"transformers","included_sources":["evil.com"]}`))
+	if !res.Result.IsError || !strings.Contains(res.text(), "evil.com") {
+		t.Errorf("search outside the allowlist = %+v, want a tool error", res)
+	}
+	if n := f.count(); n != 1 {
+		t.Errorf("made %d upstream calls after the allowlist rejection, want 1", n)
+	}
+
+	// 0.3 of 0.5 is spent; the next call may use the rest and the one
+	// after it is refused.
+	if res := send(searchCall(5, `{"query":"more"}`)); res.Result.IsError {
+		t.Fatalf("second search = %+v", res)
+	}
+	res = send(searchCall(6, `{"query":"too much"}`))
+	if !res.Result.IsError || !strings.Contains(res.text(), "spend limit of $0.50") {
+		t.Errorf("search over the spend cap = %+v, want a spend limit error", res)
+	}
+	if n := f.count(); n != 2 {
+		t.Errorf("made %d upstream calls, want 2", n)
+	}
+}
+
+func TestRunStdio(t *testing.T) {
+	f, baseURL := newFakeValyu(t)
+	stdinR, stdinW := io.Pipe()
+	stdoutR, stdoutW := io.Pipe()
+	var stderr syncBuffer
+
+	done := make(chan error, 1)
+	go func() {
+		done <- run(context.Background(), []string{
+			"-api-key", "test-key",
+			"-base-url", baseURL,
+			"-allowed-sources", "arxiv.org",
+			"-max-session-spend", "0.5",
+		}, stdinR, stdoutW, &stderr)
+		stdoutW.Close()
+	}()
+
+	replies := bufio.NewScanner(stdoutR)
+	checkSession(t, f, func(msg string) rpcReply {
+		t.Helper()
+		if _, err := io.WriteString(stdinW, msg+"\n"); err != nil {
+			t.Fatal(err)
+		}
+		if !replies.Scan() {
+			t.Fatalf("no reply to %s: %v\n%s", msg, replies.Err(), stderr.String())
+		}
+		var r rpcReply
+		if err := json.Unmarshal(replies.Bytes(), &r); err != nil {
+			t.Fatalf("reply %q: %v", replies.Text(), err)
+		}
+		return r
+	})
+
+	stdinW.Close()
+	if err := <-done; err != nil {
+		t.Errorf("run = %v", err)
+	}
+}
+
+func TestRunHTTP(t *testing.T) {
+	f, baseURL := newFakeValyu(t)
+	var stderr syncBuffer
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- run(ctx, []string{
+			"-transport", "http",
+			"-addr", "127.0.0.1:0",
+			"-api-key", "test-key",
+			"-base-url", baseURL,
+			"-allowed-sources", "arxiv.org",
+			"-max-session-spend", "0.5",
+			"-max-spend", "0.8",
+			"-max-sessions", "2",
+		}, strings.NewReader(""), io.Discard, &stderr)
+	}()
+	client := &http.Client{Transport: &http.Transport{}}
+	defer func() {
+		client.CloseIdleConnections()
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("run = %v", err)
+		}
+	}()
+
+	urlRE := regexp.MustCompile(`url=(http://\S+)`)
+	var endpoint string
+	for deadline := time.Now().Add(5 * time.Second); endpoint == ""; {
+		if m := urlRE.FindStringSubmatch(stderr.String()); m != nil {
+			endpoint = m[1]
+		} else if time.Now().After(deadline) {
+			t.Fatalf("server did not start:\n%s", stderr.String())
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	post := func(sessionID, msg string) (*http.Response, rpcReply) {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodPost, endpoint, strings.NewReader(msg))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json, text/event-stream")
+		if sessionID != "" {
+			req.Header.Set(sessionHeader, sessionID)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var r rpcReply
+		if resp.StatusCode == http.StatusOK {
+			if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+				t.Fatalf("decoding reply to %s: %v", msg, err)
+			}
+		}
+		return resp, r
+	}
+
+	var sessionID string
+	checkSession(t, f, func(msg string) rpcReply {
+		t.Helper()
+		resp, r := post(sessionID, msg)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("%s: status %d", msg, resp.StatusCode)
+		}
+		if sessionID == "" {
+			sessionID = resp.Header.Get(sessionHeader)
+		}
+		return r
+	})
+
+	// A new session has its own cap but shares the server's: 0.6 of 0.8
+	// is spent, so it gets one call.
+	initMsg := rpc(1, "initialize", `{"protocolVersion":"2025-03-26"}`)
+	resp, _ := post("", initMsg)
+	second := resp.Header.Get(sessionHeader)
+	if resp.StatusCode != http.StatusOK || second == "" {
+		t.Fatalf("second initialize: status %d", resp.StatusCode)
+	}
+	if _, r := post(second, searchCall(2, `{"query":"new session"}`)); r.Result.IsError {
+		t.Errorf("first search in a new session = %+v", r)
+	}
+	if _, r := post(second, searchCall(3, `{"query":"fresh budget?"}`)); !r.Result.IsError || !strings.Contains(r.text(), "spend limit of $0.80") {
+		t.Errorf("search over the server cap = %+v, want a server spend limit error", r)
+	}
+	if n := f.count(); n != 3 {
+		t.Errorf("made %d upstream calls, want 3", n)
+	}
+
+	// Two sessions are open, which is the cap.
+	if resp, _ := post("", initMsg); resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("third initialize: status %d, want 503", resp.StatusCode)
+	}
+	req, _ := http.NewRequest(http.MethodDelete, endpoint, nil)
+	req.Header.Set(sessionHeader, second)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("DELETE session: status %d, want 204", resp.StatusCode)
+	}
+	if resp, _ := post("", initMsg); resp.StatusCode != http.StatusOK {
+		t.Errorf("initialize after closing a session: status %d, want 200", resp.StatusCode)
+	}
+}
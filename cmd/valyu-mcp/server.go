@@ -0,0 +1,280 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"github.com/Veri5ied/valyu-go/valyu"
+	"github.com/Veri5ied/valyu-go/valyu/tools"
+)
+
+// Protocol versions this server speaks, newest first.
+var protocolVersions = []string{"2025-06-18", "2025-03-26", "2024-11-05"}
+
+// JSON-RPC error codes.
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+)
+
+type server struct {
+	client         *valyu.Client
+	logger         *slog.Logger
+	allowedSources []string
+	maxTokens      int
+
+	// maxSessionSpend caps each session's dispatcher; budget, if set, is
+	// shared by all of them so new sessions cannot reset the server's cap.
+	maxSessionSpend float64
+	budget          *tools.Budget
+}
+
+// session is the state of one MCP client: its negotiated version, its
+// spending and its in-flight requests.
+type session struct {
+	id     string
+	srv    *server
+	tools  *tools.Dispatcher
+	logger *slog.Logger
+
+	mu              sync.Mutex
+	protocolVersion string
+	inflight        map[string]context.CancelFunc
+}
+
+func (s *server) newSession(id string) *session {
+	opts := []tools.Option{tools.WithMaxTokens(s.maxTokens)}
+	if len(s.allowedSources) > 0 {
+		opts = append(opts, tools.WithAllowedSources(s.allowedSources...))
+	}
+	if s.maxSessionSpend > 0 {
+		opts = append(opts, tools.WithSpendLimit(s.maxSessionSpend))
+	}
+	if s.budget != nil {
+		opts = append(opts, tools.WithBudget(s.budget))
+	}
+	return &session{
+		id:       id,
+		srv:      s,
+		tools:    tools.NewDispatcher(s.client, opts...),
+		logger:   s.logger.With("session", id),
+		inflight: map[string]context.CancelFunc{},
+	}
+}
+
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func errorResponse(id json.RawMessage, code int, format string, args ...interface{}) *response {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+	return &response{JSONRPC: "2.0", ID: id, Error: &rpcError{Code: code, Message: fmt.Sprintf(format, args...)}}
+}
+
+// handle processes one JSON-RPC message or batch and returns the encoded
+// reply, or nil if there is nothing to send back.
+func (ss *session) handle(ctx context.Context, data []byte) []byte {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '[' {
+		var batch []json.RawMessage
+		if err := json.Unmarshal(data, &batch); err != nil || len(batch) == 0 {
+			return encode(errorResponse(nil, codeInvalidRequest, "invalid batch"))
+		}
+		var replies []*response
+		for _, msg := range batch {
+			if resp := ss.handleMessage(ctx, msg); resp != nil {
+				replies = append(replies, resp)
+			}
+		}
+		if len(replies) == 0 {
+			return nil
+		}
+		return encode(replies)
+	}
+	if resp := ss.handleMessage(ctx, data); resp != nil {
+		return encode(resp)
+	}
+	return nil
+}
+
+func (ss *session) handleMessage(ctx context.Context, data []byte) *response {
+	var req request
+	if err := json.Unmarshal(data, &req); err != nil {
+		return errorResponse(nil, codeParseError, "parse error: %v", err)
+	}
+	if req.Method == "" {
+		// A response to a server request; this server sends none.
+		return nil
+	}
+	if req.ID == nil {
+		ss.notify(req)
+		return nil
+	}
+
+	ss.logger.Debug("mcp request", "method", req.Method, "id", string(req.ID))
+	result, rerr := ss.call(ctx, req)
+	if rerr != nil {
+		return &response{JSONRPC: "2.0", ID: req.ID, Error: rerr}
+	}
+	return &response{JSONRPC: "2.0", ID: req.ID, Result: result}
+}
+
+func (ss *session) notify(req request) {
+	switch req.Method {
+	case "notifications/cancelled":
+		var p struct {
+			RequestID json.RawMessage `json:"requestId"`
+		}
+		if json.Unmarshal(req.Params, &p) == nil {
+			ss.mu.Lock()
+			cancel := ss.inflight[string(p.RequestID)]
+			ss.mu.Unlock()
+			if cancel != nil {
+				cancel()
+			}
+		}
+	}
+}
+
+func (ss *session) call(ctx context.Context, req request) (interface{}, *rpcError) {
+	switch req.Method {
+	case "initialize":
+		var p struct {
+			ProtocolVersion string `json:"protocolVersion"`
+		}
+		_ = json.Unmarshal(req.Params, &p)
+		version := protocolVersions[0]
+		for _, v := range protocolVersions {
+			if v == p.ProtocolVersion {
+				version = v
+			}
+		}
+		ss.mu.Lock()
+		ss.protocolVersion = version
+		ss.mu.Unlock()
+		return map[string]interface{}{
+			"protocolVersion": version,
+			"capabilities": map[string]interface{}{
+				"tools": map[string]interface{}{"listChanged": false},
+			},
+			"serverInfo": map[string]interface{}{
+				"name":    "valyu-mcp",
+				"version": valyu.Version(),
+			},
+			"instructions": ss.srv.instructions(),
+		}, nil
+
+	case "ping":
+		return map[string]interface{}{}, nil
+
+	case "tools/list":
+		var list []map[string]interface{}
+		for _, t := range ss.tools.Tools() {
+			list = append(list, map[string]interface{}{
+				"name":        t.Name,
+				"description": t.Description,
+				"inputSchema": t.Parameters,
+			})
+		}
+		return map[string]interface{}{"tools": list}, nil
+
+	case "tools/call":
+		var p struct {
+			Name      string          `json:"name"`
+			Arguments json.RawMessage `json:"arguments"`
+		}
+		if err := json.Unmarshal(req.Params, &p); err != nil {
+			return nil, &rpcError{Code: codeInvalidParams, Message: "invalid params: " + err.Error()}
+		}
+		if !ss.hasTool(p.Name) {
+			return nil, &rpcError{Code: codeInvalidParams, Message: "unknown tool: " + p.Name}
+		}
+		if len(p.Arguments) == 0 || string(p.Arguments) == "null" {
+			p.Arguments = json.RawMessage("{}")
+		}
+
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		key := string(req.ID)
+		ss.mu.Lock()
+		ss.inflight[key] = cancel
+		ss.mu.Unlock()
+		defer func() {
+			ss.mu.Lock()
+			delete(ss.inflight, key)
+			ss.mu.Unlock()
+		}()
+
+		res := ss.tools.Do(ctx, tools.Call{Name: p.Name, Arguments: p.Arguments})
+		ss.logger.Info("tool call", "tool", p.Name, "error", res.IsError,
+			"cost", res.Cost, "spent", ss.tools.Spent(), "server_spent", ss.srv.spent())
+		return map[string]interface{}{
+			"content": []map[string]interface{}{{"type": "text", "text": res.Content}},
+			"isError": res.IsError,
+		}, nil
+	}
+	return nil, &rpcError{Code: codeMethodNotFound, Message: "method not found: " + req.Method}
+}
+
+func (ss *session) hasTool(name string) bool {
+	for _, t := range ss.tools.Tools() {
+		if t.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *server) spent() float64 {
+	if s.budget == nil {
+		return 0
+	}
+	return s.budget.Spent()
+}
+
+func (s *server) instructions() string {
+	var b strings.Builder
+	b.WriteString("Use valyu_search for raw results, valyu_answer for a sourced answer, valyu_contents to read pages, valyu_datasources to discover datasets and valyu_deepresearch for long reports.")
+	if len(s.allowedSources) > 0 {
+		fmt.Fprintf(&b, " Only these sources are available: %s.", strings.Join(s.allowedSources, ", "))
+	}
+	if s.maxSessionSpend > 0 {
+		fmt.Fprintf(&b, " This session may spend at most $%.2f.", s.maxSessionSpend)
+	}
+	if s.budget != nil {
+		fmt.Fprintf(&b, " The server may spend at most $%.2f across all sessions.", s.budget.Limit())
+	}
+	return b.String()
+}
+
+func encode(v interface{}) []byte {
+	b, err := json.Marshal(v)
+	if err != nil {
+		b, _ = json.Marshal(errorResponse(nil, codeInvalidRequest, "encoding response: %v", err))
+	}
+	return b
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"sync"
+)
+
+// maxMessageSize bounds a single JSON-RPC message.
+const maxMessageSize = 4 << 20
+
+// serveStdio reads newline-delimited JSON-RPC messages from in and writes
+// replies to out until in is closed. Requests run concurrently so a slow
+// tool call does not hold up pings or cancellations.
+func serveStdio(ctx context.Context, srv *server, in io.Reader, out io.Writer) error {
+	sess := srv.newSession("stdio")
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	write := func(b []byte) {
+		mu.Lock()
+		defer mu.Unlock()
+		out.Write(append(b, '\n'))
+	}
+
+	sc := bufio.NewScanner(in)
+	sc.Buffer(make([]byte, 64<<10), maxMessageSize)
+	for sc.Scan() {
+		line := append([]byte(nil), sc.Bytes()...)
+		if len(line) == 0 {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if reply := sess.handle(ctx, line); reply != nil {
+				write(reply)
+			}
+		}()
+	}
+	wg.Wait()
+	return sc.Err()
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/Veri5ied/valyu-go/valyu/answer"
+	"github.com/Veri5ied/valyu-go/valyu/common"
+)
+
+func runAnswer(e *env, args []string) error {
+	fs := newFlagSet(e, "answer", "<query>")
+	var (
+		cf   clientFlags
+		of   outputFlags
+		opts answer.Options
+		kind string
+		incl listFlag
+		excl listFlag
+	)
+	cf.register(fs)
+	of.register(fs)
+	fs.StringVar(&kind, "type", "", "search type: all, web, proprietary or news")
+	fs.Var(&incl, "sources", "comma-separated domains and dataset IDs to search")
+	fs.Var(&excl, "exclude", "comma-separated domains and dataset IDs to skip")
+	fs.StringVar(&opts.SystemInstructions, "instructions", "", "system instructions for the answer")
+	fs.Float64Var(&opts.DataMaxPrice, "max-price", 0, "maximum dollars to spend on search data")
+	fs.StringVar(&opts.StartDate, "start", "", "earliest publication date, YYYY-MM-DD")
+	fs.StringVar(&opts.EndDate, "end", "", "latest publication date, YYYY-MM-DD")
+	fs.BoolVar(&opts.FastMode, "fast", false, "trade answer quality for latency")
+	pos, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	f, err := of.format()
+	if err != nil {
+		return err
+	}
+	q, err := query(e, pos)
+	if err != nil {
+		return err
+	}
+	client, err := cf.client(e)
+	if err != nil {
+		return err
+	}
+	opts.SearchType = common.SearchType(kind)
+	opts.IncludedSources = incl
+	opts.ExcludedSources = excl
+
+	switch f {
+	case formatJSONL:
+		return streamChunks(e, client.Answer, q, &opts)
+	case formatJSON:
+		resp, err := client.Answer.Answer(e.ctx, q, &opts)
+		if err != nil {
+			return err
+		}
+		return printView(e.stdout, f, view{raw: resp})
+	case formatMarkdown:
+		// Footnotes need the whole text, so Markdown is not streamed.
+		resp, err := client.Answer.Answer(e.ctx, q, &opts)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(e.stdout, resp.Citations().Markdown())
+		return nil
+	}
+
+	resp, err := client.Answer.StreamTo(e.ctx, e.stdout, q, &opts)
+	if err != nil {
+		fmt.Fprintln(e.stdout)
+		return err
+	}
+	fmt.Fprint(e.stdout, "\n\n")
+	if len(resp.SearchResults) > 0 {
+		var rows [][]string
+		for i, r := range resp.SearchResults {
+			rows = append(rows, []string{"[" + strconv.Itoa(i+1) + "]", r.Title, r.URL})
+		}
+		if err := writeTable(e.stdout, []string{"SOURCE", "TITLE", "URL"}, rows); err != nil {
+			return err
+		}
+	}
+	if cost := resp.Cost.TotalDeductionDollars; cost > 0 {
+		fmt.Fprintf(e.stderr, "cost: %s\n", dollars(cost))
+	}
+	return nil
+}
+
+// streamChunks writes every stream chunk as a JSON line as it arrives.
+func streamChunks(e *env, s *answer.Service, q string, opts *answer.Options) error {
+	stream, err := s.OpenStream(e.ctx, q, opts)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	enc := json.NewEncoder(e.stdout)
+	enc.SetEscapeHTML(false)
+	for stream.Next() {
+		if err := enc.Encode(stream.Chunk()); err != nil {
+			return err
+		}
+	}
+	return stream.Err()
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"strconv"
+
+	"github.com/Veri5ied/valyu-go/valyu/batch"
+	"github.com/Veri5ied/valyu-go/valyu/common"
+)
+
+func runBatch(e *env, args []string) error {
+	return subcommand(e, "batch", map[string]command{
+		"create": runBatchCreate,
+		"get":    runBatchGet,
+		"list":   runBatchList,
+	}, args)
+}
+
+func runBatchCreate(e *env, args []string) error {
+	fs := newFlagSet(e, "batch create", "")
+	var (
+		cf      clientFlags
+		of      outputFlags
+		opts    batch.CreateOptions
+		mode    string
+		formats listFlag
+	)
+	cf.register(fs)
+	of.register(fs)
+	fs.StringVar(&opts.Name, "name", "", "batch name")
+	fs.StringVar(&mode, "mode", "", "research mode of the tasks: fast, standard or heavy")
+	fs.Var(&formats, "format", "comma-separated output formats, e.g. markdown,pdf")
+	fs.StringVar(&opts.WebhookURL, "webhook", "", "URL to notify on completion")
+	if _, err := parse(fs, args); err != nil {
+		return err
+	}
+	f, err := of.format()
+	if err != nil {
+		return err
+	}
+	client, err := cf.client(e)
+	if err != nil {
+		return err
+	}
+
+	opts.Mode = common.DeepResearchMode(mode)
+	opts.OutputFormats = formats
+	resp, err := client.Batch.Create(e.ctx, &opts)
+	if err != nil {
+		return err
+	}
+	return printView(e.stdout, f, fieldsView(resp, [][2]string{
+		{"ID", resp.BatchID},
+		{"NAME", resp.Name},
+		{"STATUS", string(resp.Status)},
+		{"MODE", string(resp.Mode)},
+		{"CREATED", resp.CreatedAt},
+	}))
+}
+
+func runBatchGet(e *env, args []string) error {
+	fs := newFlagSet(e, "batch get", "<id>")
+	var (
+		cf clientFlags
+		of outputFlags
+	)
+	cf.register(fs)
+	of.register(fs)
+	id, f, err := parseID(fs, &of, args)
+	if err != nil {
+		return err
+	}
+	client, err := cf.client(e)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Batch.Get(e.ctx, id)
+	if err != nil {
+		return err
+	}
+	b := resp.Batch
+	if b == nil {
+		b = &batch.Batch{BatchID: id}
+	}
+	return printView(e.stdout, f, fieldsView(resp, [][2]string{
+		{"ID", b.BatchID},
+		{"NAME", b.Name},
+		{"STATUS", string(b.Status)},
+		{"MODE", string(b.Mode)},
+		{"TASKS", batchCounts(b.Counts)},
+		{"COST", dollars(b.Cost)},
+		{"CREATED", b.CreatedAt},
+		{"COMPLETED", b.CompletedAt},
+	}))
+}
+
+func runBatchList(e *env, args []string) error {
+	fs := newFlagSet(e, "batch list", "")
+	var (
+		cf clientFlags
+		of outputFlags
+	)
+	cf.register(fs)
+	of.register(fs)
+	if _, err := parse(fs, args); err != nil {
+		return err
+	}
+	f, err := of.format()
+	if err != nil {
+		return err
+	}
+	client, err := cf.client(e)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Batch.List(e.ctx)
+	if err != nil {
+		return err
+	}
+
+	v := view{
+		raw:     resp,
+		records: records(resp.Batches),
+		columns: []string{"ID", "NAME", "STATUS", "TASKS", "COST", "CREATED"},
+	}
+	for _, b := range resp.Batches {
+		v.rows = append(v.rows, []string{b.BatchID, b.Name, string(b.Status), batchCounts(b.Counts), dollars(b.Cost), b.CreatedAt})
+	}
+	return printView(e.stdout, f, v)
+}
+
+func batchCounts(c batch.Counts) string {
+	if c.Total == 0 {
+		return "0"
+	}
+	s := strconv.Itoa(c.Completed) + "/" + strconv.Itoa(c.Total) + " done"
+	if c.Running > 0 {
+		s += ", " + strconv.Itoa(c.Running) + " running"
+	}
+	if c.Failed > 0 {
+		s += ", " + strconv.Itoa(c.Failed) + " failed"
+	}
+	return s
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/Veri5ied/valyu-go/valyu"
+)
+
+type config struct {
+	APIKey  string `json:"api_key"`
+	BaseURL string `json:"base_url"`
+}
+
+// clientFlags are the connection flags shared by every command.
+type clientFlags struct {
+	apiKey     string
+	baseURL    string
+	configPath string
+	verbose    bool
+}
+
+func (f *clientFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.apiKey, "api-key", "", "Valyu API key (default $VALYU_API_KEY or the config file)")
+	fs.StringVar(&f.baseURL, "base-url", "", "Valyu API base URL (default $VALYU_BASE_URL or the config file)")
+	fs.StringVar(&f.configPath, "config", "", "config file (default $VALYU_CONFIG or "+configPathHint()+")")
+	fs.BoolVar(&f.verbose, "v", false, "log API requests to stderr")
+}
+
+// client builds a Valyu client from the flags, the environment and the
+// config file, in that order of precedence.
+func (f *clientFlags) client(e *env) (*valyu.Client, error) {
+	cfg, err := loadConfig(f.configPath)
+	if err != nil {
+		return nil, err
+	}
+	apiKey := first(f.apiKey, os.Getenv("VALYU_API_KEY"), cfg.APIKey)
+	if apiKey == "" {
+		return nil, errors.New(`no API key: set VALYU_API_KEY, pass --api-key or add "api_key" to the config file (see "valyu help config")`)
+	}
+
+	opts := []valyu.Option{
+		valyu.WithBaseURL(first(f.baseURL, os.Getenv("VALYU_BASE_URL"), cfg.BaseURL, valyu.DefaultBaseURL)),
+		valyu.WithUserAgent("valyu-cli"),
+		valyu.WithRetry(valyu.DefaultRetryPolicy),
+		// Answers and deep research can outlive the default timeout;
+		// Ctrl-C cancels instead.
+		valyu.WithTimeout(0),
+	}
+	if f.verbose {
+		logger := slog.New(slog.NewTextHandler(e.stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+		opts = append(opts, valyu.WithLogger(logger), valyu.WithLogLevel(slog.LevelDebug))
+	}
+	return valyu.New(apiKey, opts...)
+}
+
+// loadConfig reads the config file. A missing file at the default location
+// is not an error; a missing file that was asked for explicitly is.
+func loadConfig(path string) (config, error) {
+	var cfg config
+	explicit := true
+	if path == "" {
+		path = os.Getenv("VALYU_CONFIG")
+	}
+	if path == "" {
+		explicit = false
+		dir, err := os.UserConfigDir()
+		if err != nil {
+			return cfg, nil
+		}
+		path = filepath.Join(dir, "valyu", "config.json")
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) && !explicit {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, err
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("config %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+func configPathHint() string {
+	if dir, err := os.UserConfigDir(); err == nil {
+		return filepath.Join(dir, "valyu", "config.json")
+	}
+	return "valyu/config.json in the user config directory"
+}
+
+func first(ss ...string) string {
+	for _, s := range ss {
+		if s != "" {
+			return s
+		}
+	}
+	return ""
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/Veri5ied/valyu-go/valyu/common"
+	"github.com/Veri5ied/valyu-go/valyu/contents"
+)
+
+func runContents(e *env, args []string) error {
+	fs := newFlagSet(e, "contents", "<url>...")
+	var (
+		cf      clientFlags
+		of      outputFlags
+		opts    contents.Options
+		summary string
+		length  string
+		effort  string
+	)
+	cf.register(fs)
+	of.register(fs)
+	fs.StringVar(&summary, "summary", "", `summarize each page: "true" or an instruction`)
+	fs.StringVar(&length, "length", "", "content length: short, medium, large or max")
+	fs.StringVar(&effort, "effort", "", "extraction effort: normal, high or auto")
+	fs.Float64Var(&opts.MaxPriceDollars, "max-price", 0, "maximum dollars to spend")
+	fs.BoolVar(&opts.Screenshot, "screenshot", false, "capture a screenshot of each page")
+	urls, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	f, err := of.format()
+	if err != nil {
+		return err
+	}
+	if len(urls) == 0 {
+		return errors.New("at least one URL is required")
+	}
+	client, err := cf.client(e)
+	if err != nil {
+		return err
+	}
+
+	switch summary {
+	case "":
+	case "true":
+		opts.Summary = true
+	default:
+		opts.Summary = summary
+	}
+	opts.ResponseLength = common.ResponseLength(length)
+	opts.ExtractEffort = common.ExtractEffort(effort)
+	resp, err := client.Contents.Get(e.ctx, urls, &opts)
+	if err != nil {
+		return err
+	}
+
+	v := view{
+		raw:     resp,
+		records: records(resp.Results),
+		columns: []string{"URL", "TITLE", "LENGTH", "PRICE", "SUMMARY"},
+	}
+	for _, r := range resp.Results {
+		v.rows = append(v.rows, []string{r.URL, r.Title, strconv.Itoa(r.Length), dollars(r.Price), text(r.Summary)})
+	}
+	v.markdown = func(w io.Writer) {
+		for i, r := range resp.Results {
+			if i > 0 {
+				fmt.Fprint(w, "\n---\n\n")
+			}
+			fmt.Fprintf(w, "# %s\n\n<%s>\n\n", first(r.Title, r.URL), r.URL)
+			if r.Summary != nil {
+				fmt.Fprintf(w, "%s\n\n", text(r.Summary))
+			}
+			fmt.Fprintln(w, text(r.Content))
+		}
+	}
+	return printView(e.stdout, f, v)
+}
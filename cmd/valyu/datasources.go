@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Veri5ied/valyu-go/valyu/datasources"
+)
+
+func runDatasources(e *env, args []string) error {
+	fs := newFlagSet(e, "datasources", "")
+	var (
+		cf       clientFlags
+		of       outputFlags
+		category string
+	)
+	cf.register(fs)
+	of.register(fs)
+	fs.StringVar(&category, "category", "", "only list datasets in this category, e.g. research or markets")
+	if _, err := parse(fs, args); err != nil {
+		return err
+	}
+	f, err := of.format()
+	if err != nil {
+		return err
+	}
+	client, err := cf.client(e)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Datasources.List(e.ctx)
+	if err != nil {
+		return err
+	}
+
+	list := resp.Datasources
+	if category != "" {
+		var filtered []datasources.Datasource
+		for _, d := range list {
+			if strings.EqualFold(string(d.Category), category) {
+				filtered = append(filtered, d)
+			}
+		}
+		list = filtered
+		resp = &datasources.ListResponse{Success: resp.Success, Datasources: filtered}
+	}
+
+	v := view{
+		raw:     resp,
+		records: records(list),
+		columns: []string{"ID", "NAME", "CATEGORY", "CPM", "DESCRIPTION"},
+	}
+	for _, d := range list {
+		v.rows = append(v.rows, []string{d.ID, d.Name, string(d.Category), fmt.Sprintf("$%.2f", d.Pricing.CPM), d.Description})
+	}
+	v.markdown = func(w io.Writer) {
+		for _, d := range list {
+			fmt.Fprintf(w, "## %s\n\n`%s` · %s · $%.2f per 1k results\n\n", d.Name, d.ID, d.Category, d.Pricing.CPM)
+			if d.Description != "" {
+				fmt.Fprintf(w, "%s\n\n", d.Description)
+			}
+		}
+	}
+	return printView(e.stdout, f, v)
+}
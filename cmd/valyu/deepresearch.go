@@ -0,0 +1,419 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Veri5ied/valyu-go/valyu"
+	"github.com/Veri5ied/valyu-go/valyu/common"
+	"github.com/Veri5ied/valyu-go/valyu/deepresearch"
+)
+
+func runDeepResearch(e *env, args []string) error {
+	return subcommand(e, "deepresearch", map[string]command{
+		"create":   runDeepResearchCreate,
+		"get":      runDeepResearchGet,
+		"list":     runDeepResearchList,
+		"wait":     runDeepResearchWait,
+		"download": runDeepResearchDownload,
+	}, args)
+}
+
+func runDeepResearchCreate(e *env, args []string) error {
+	fs := newFlagSet(e, "deepresearch create", "<query>")
+	var (
+		cf       clientFlags
+		of       outputFlags
+		opts     deepresearch.CreateOptions
+		search   deepresearch.SearchConfig
+		mode     string
+		formats  listFlag
+		urls     listFlag
+		wait     bool
+		interval time.Duration
+	)
+	cf.register(fs)
+	of.register(fs)
+	fs.StringVar(&mode, "mode", "", "research mode: fast, standard or heavy")
+	fs.Var(&formats, "format", "comma-separated output formats, e.g. markdown,pdf")
+	fs.Var(&urls, "url", "URL to include in the research; repeatable")
+	fs.StringVar(&search.SearchType, "type", "", "search type: all, web or proprietary")
+	fs.Var((*listFlag)(&search.IncludedSources), "sources", "comma-separated domains and dataset IDs to search")
+	fs.Var((*listFlag)(&search.ExcludedSources), "exclude", "comma-separated domains and dataset IDs to skip")
+	fs.StringVar(&opts.WebhookURL, "webhook", "", "URL to notify on completion")
+	fs.BoolVar(&wait, "wait", false, "wait for the task to finish and print the report")
+	fs.DurationVar(&interval, "interval", 10*time.Second, "polling interval for --wait")
+	pos, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	f, err := of.format()
+	if err != nil {
+		return err
+	}
+	q, err := query(e, pos)
+	if err != nil {
+		return err
+	}
+	client, err := cf.client(e)
+	if err != nil {
+		return err
+	}
+
+	opts.Query = q
+	opts.Mode = common.DeepResearchMode(mode)
+	opts.OutputFormats = formats
+	opts.URLs = urls
+	if search.SearchType != "" || len(search.IncludedSources) > 0 || len(search.ExcludedSources) > 0 {
+		opts.Search = &search
+	}
+	resp, err := client.DeepResearch.Create(e.ctx, &opts)
+	if err != nil {
+		return err
+	}
+	if !wait {
+		return printView(e.stdout, f, fieldsView(resp, [][2]string{
+			{"ID", resp.DeepResearchID},
+			{"STATUS", string(resp.Status)},
+			{"MODE", string(resp.Mode)},
+			{"CREATED", resp.CreatedAt},
+			{"MESSAGE", resp.Message},
+		}))
+	}
+	fmt.Fprintln(e.stderr, "created", resp.DeepResearchID)
+	return waitAndPrint(e, client, resp.DeepResearchID, interval, f)
+}
+
+func runDeepResearchGet(e *env, args []string) error {
+	fs := newFlagSet(e, "deepresearch get", "<id>")
+	var (
+		cf clientFlags
+		of outputFlags
+	)
+	cf.register(fs)
+	of.register(fs)
+	id, f, err := parseID(fs, &of, args)
+	if err != nil {
+		return err
+	}
+	client, err := cf.client(e)
+	if err != nil {
+		return err
+	}
+	st, err := client.DeepResearch.Get(e.ctx, id)
+	if err != nil {
+		return err
+	}
+	return printView(e.stdout, f, statusView(st))
+}
+
+func runDeepResearchList(e *env, args []string) error {
+	fs := newFlagSet(e, "deepresearch list", "")
+	var (
+		cf clientFlags
+		of outputFlags
+	)
+	cf.register(fs)
+	of.register(fs)
+	if _, err := parse(fs, args); err != nil {
+		return err
+	}
+	f, err := of.format()
+	if err != nil {
+		return err
+	}
+	client, err := cf.client(e)
+	if err != nil {
+		return err
+	}
+	resp, err := client.DeepResearch.List(e.ctx)
+	if err != nil {
+		return err
+	}
+
+	v := view{
+		raw:     resp,
+		records: records(resp.Data),
+		columns: []string{"ID", "STATUS", "CREATED", "QUERY"},
+	}
+	for _, item := range resp.Data {
+		v.rows = append(v.rows, []string{item.DeepResearchID, string(item.Status), unixTime(item.CreatedAt), item.Query})
+	}
+	return printView(e.stdout, f, v)
+}
+
+func runDeepResearchWait(e *env, args []string) error {
+	fs := newFlagSet(e, "deepresearch wait", "<id>")
+	var (
+		cf       clientFlags
+		of       outputFlags
+		interval time.Duration
+	)
+	cf.register(fs)
+	of.register(fs)
+	fs.DurationVar(&interval, "interval", 10*time.Second, "polling interval")
+	id, f, err := parseID(fs, &of, args)
+	if err != nil {
+		return err
+	}
+	client, err := cf.client(e)
+	if err != nil {
+		return err
+	}
+	return waitAndPrint(e, client, id, interval, f)
+}
+
+func runDeepResearchDownload(e *env, args []string) error {
+	fs := newFlagSet(e, "deepresearch download", "<id>")
+	var (
+		cf  clientFlags
+		dir string
+	)
+	cf.register(fs)
+	fs.StringVar(&dir, "o", ".", "directory to write the files to")
+	pos, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(pos) != 1 {
+		return errors.New("exactly one deep research ID is required")
+	}
+	id := pos[0]
+	client, err := cf.client(e)
+	if err != nil {
+		return err
+	}
+	st, err := client.DeepResearch.Get(e.ctx, id)
+	if err != nil {
+		return err
+	}
+	if st.Status != common.DeepResearchStatusCompleted {
+		return fmt.Errorf("deep research %s is %s, not completed", id, st.Status)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	save := func(name string, write func(w io.Writer) error) error {
+		p := filepath.Join(dir, name)
+		f, err := os.Create(p)
+		if err != nil {
+			return err
+		}
+		if err := write(f); err != nil {
+			f.Close()
+			os.Remove(p)
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+		fmt.Fprintln(e.stdout, p)
+		return nil
+	}
+	fetch := func(url string) func(w io.Writer) error {
+		return func(w io.Writer) error { return download(e, url, w) }
+	}
+
+	if st.Output != nil {
+		name, body := id+".md", text(st.Output)
+		if _, ok := st.Output.(string); !ok {
+			name = id + ".json"
+		}
+		if err := save(name, func(w io.Writer) error {
+			_, err := io.WriteString(w, body+"\n")
+			return err
+		}); err != nil {
+			return err
+		}
+	}
+	if st.PDFURL != "" {
+		if err := save(id+".pdf", fetch(st.PDFURL)); err != nil {
+			return err
+		}
+	}
+	for i, d := range st.Deliverables {
+		if d.URL == "" {
+			continue
+		}
+		name := fmt.Sprintf("%s-deliverable-%d-%s%s", id, i+1, slug(first(d.Title, d.Type)), urlExt(d.URL))
+		if err := save(name, fetch(d.URL)); err != nil {
+			return err
+		}
+	}
+	for i, img := range st.Images {
+		if img.ImageURL == "" {
+			continue
+		}
+		name := fmt.Sprintf("%s-image-%d-%s%s", id, i+1, slug(first(img.Title, img.ImageType)), urlExt(img.ImageURL))
+		if err := save(name, fetch(img.ImageURL)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// waitAndPrint polls a task until it finishes, reporting progress on
+// stderr, and prints its final status. A failed or cancelled task is an
+// error.
+func waitAndPrint(e *env, client *valyu.Client, id string, interval time.Duration, f format) error {
+	var last string
+	for {
+		st, err := client.DeepResearch.Get(e.ctx, id)
+		if err != nil {
+			return err
+		}
+		switch st.Status {
+		case common.DeepResearchStatusCompleted:
+			return printView(e.stdout, f, statusView(st))
+		case common.DeepResearchStatusFailed, common.DeepResearchStatusCancelled:
+			if err := printView(e.stdout, f, statusView(st)); err != nil {
+				return err
+			}
+			return fmt.Errorf("deep research %s %s: %s", id, st.Status, first(st.Error, "no error message"))
+		}
+
+		progress := string(st.Status)
+		if p := st.Progress; p != nil && p.TotalSteps > 0 {
+			progress += fmt.Sprintf(" (step %d of %d)", p.CurrentStep, p.TotalSteps)
+		}
+		if progress != last {
+			fmt.Fprintf(e.stderr, "%s: %s\n", id, progress)
+			last = progress
+		}
+
+		t := time.NewTimer(interval)
+		select {
+		case <-e.ctx.Done():
+			t.Stop()
+			return e.ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+func statusView(st *deepresearch.StatusResponse) view {
+	var progress string
+	if p := st.Progress; p != nil && p.TotalSteps > 0 {
+		progress = fmt.Sprintf("%d/%d", p.CurrentStep, p.TotalSteps)
+	}
+	v := fieldsView(st, [][2]string{
+		{"ID", st.DeepResearchID},
+		{"STATUS", string(st.Status)},
+		{"MODE", string(st.Mode)},
+		{"QUERY", st.Query},
+		{"PROGRESS", progress},
+		{"COST", dollars(st.Cost)},
+		{"CREATED", st.CreatedAt},
+		{"COMPLETED", st.CompletedAt},
+		{"PDF", st.PDFURL},
+		{"SOURCES", strconv.Itoa(len(st.Sources))},
+		{"DELIVERABLES", strconv.Itoa(len(st.Deliverables))},
+		{"ERROR", st.Error},
+	})
+	v.markdown = func(w io.Writer) {
+		if st.Output != nil {
+			fmt.Fprintln(w, text(st.Output))
+		} else {
+			fmt.Fprintf(w, "_Deep research %s is %s._\n", st.DeepResearchID, st.Status)
+		}
+		if len(st.Sources) > 0 {
+			fmt.Fprint(w, "\n## Sources\n\n")
+			for i, s := range st.Sources {
+				fmt.Fprintf(w, "%d. [%s](%s)\n", i+1, first(s.Title, s.URL), s.URL)
+			}
+		}
+	}
+	return v
+}
+
+// fieldsView shows a single object as FIELD/VALUE rows, skipping empty
+// values.
+func fieldsView(raw interface{}, fields [][2]string) view {
+	v := view{raw: raw, columns: []string{"FIELD", "VALUE"}}
+	for _, kv := range fields {
+		if kv[1] != "" {
+			v.rows = append(v.rows, []string{kv[0], kv[1]})
+		}
+	}
+	return v
+}
+
+func parseID(fs *flag.FlagSet, of *outputFlags, args []string) (string, format, error) {
+	pos, err := parse(fs, args)
+	if err != nil {
+		return "", "", err
+	}
+	f, err := of.format()
+	if err != nil {
+		return "", "", err
+	}
+	if len(pos) != 1 {
+		return "", "", errors.New("exactly one ID is required")
+	}
+	return pos[0], f, nil
+}
+
+func download(e *env, url string, w io.Writer) error {
+	req, err := http.NewRequestWithContext(e.ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
+
+func slug(s string) string {
+	s = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + 'a' - 'A'
+		}
+		return '-'
+	}, s)
+	s = strings.Trim(s, "-")
+	for strings.Contains(s, "--") {
+		s = strings.ReplaceAll(s, "--", "-")
+	}
+	if len(s) > 40 {
+		s = strings.TrimRight(s[:40], "-")
+	}
+	return first(s, "file")
+}
+
+func urlExt(rawURL string) string {
+	if i := strings.IndexAny(rawURL, "?#"); i >= 0 {
+		rawURL = rawURL[:i]
+	}
+	return path.Ext(rawURL)
+}
+
+func unixTime(sec int64) string {
+	if sec == 0 {
+		return ""
+	}
+	// Some endpoints report milliseconds.
+	if sec > 1e12 {
+		sec /= 1000
+	}
+	return time.Unix(sec, 0).UTC().Format(time.RFC3339)
+}
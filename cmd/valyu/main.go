@@ -0,0 +1,133 @@
+// Command valyu runs Valyu searches, answers, content extraction and deep
+// research from the shell.
+//
+//	valyu search "transformer interpretability" -n 5
+//	valyu answer "What changed in the latest Fed statement?"
+//	valyu contents https://example.com/article --markdown
+//	valyu deepresearch create "State of solid-state batteries" --wait
+//	valyu search "llm evals" --jsonl | jq -r .url
+//
+// The API key is read from --api-key, the VALYU_API_KEY environment
+// variable, or the config file (see "valyu help config").
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+
+	"github.com/Veri5ied/valyu-go/valyu"
+)
+
+const usage = `Usage: valyu <command> [flags] [args]
+
+Commands:
+  search <query>             search the web and proprietary datasets
+  answer <query>             stream an AI answer grounded in search results
+  contents <url>...          extract the content of web pages
+  deepresearch <subcommand>  create, get, list, wait for or download research tasks
+  batch <subcommand>         create, get or list deep research batches
+  datasources                list the proprietary datasets
+  version                    print the version
+
+Output flags (most commands):
+  --json      the full API response, indented
+  --jsonl     one JSON object per result, for jq and friends
+  --table     aligned columns (default)
+  --markdown  Markdown
+
+Run "valyu <command> -h" for the flags of a command and "valyu help config"
+for the config file.
+`
+
+const configHelp = `The API key and base URL are taken, in order, from the --api-key and
+--base-url flags, the VALYU_API_KEY and VALYU_BASE_URL environment variables,
+and a JSON config file:
+
+  {"api_key": "...", "base_url": "https://api.valyu.ai/v1"}
+
+The file is read from $VALYU_CONFIG, or else from valyu/config.json in the
+user config directory (%s).
+`
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := run(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, "valyu:", err)
+		}
+		os.Exit(1)
+	}
+}
+
+type env struct {
+	ctx    context.Context
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+type command func(e *env, args []string) error
+
+var commands = map[string]command{
+	"search":       runSearch,
+	"answer":       runAnswer,
+	"contents":     runContents,
+	"deepresearch": runDeepResearch,
+	"batch":        runBatch,
+	"datasources":  runDatasources,
+}
+
+func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	e := &env{ctx: ctx, stdin: stdin, stdout: stdout, stderr: stderr}
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return flag.ErrHelp
+	}
+
+	name, rest := args[0], args[1:]
+	switch name {
+	case "help", "-h", "--help":
+		if len(rest) > 0 && rest[0] == "config" {
+			fmt.Fprintf(stdout, configHelp, configPathHint())
+			return nil
+		}
+		fmt.Fprint(stdout, usage)
+		return nil
+	case "version", "--version":
+		fmt.Fprintln(stdout, "valyu", valyu.Version())
+		return nil
+	}
+
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprint(stderr, usage)
+		return fmt.Errorf("unknown command %q", name)
+	}
+	return cmd(e, rest)
+}
+
+// subcommand dispatches to the named entry of subs.
+func subcommand(e *env, group string, subs map[string]command, args []string) error {
+	if len(args) == 0 {
+		var names []string
+		for name := range subs {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("usage: valyu %s <%s>", group, strings.Join(names, "|"))
+	}
+	cmd, ok := subs[args[0]]
+	if !ok {
+		return fmt.Errorf("unknown %s subcommand %q", group, args[0])
+	}
+	return cmd(e, args[1:])
+}
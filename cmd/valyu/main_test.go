@@ -0,0 +1,442 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeAPI serves /search, /contents, /datasources and a streamed /answer
+// under any path prefix, recording each request.
+type fakeAPI struct {
+	mu   sync.Mutex
+	reqs []fakeRequest
+}
+
+type fakeRequest struct {
+	Path   string
+	APIKey string
+	Body   map[string]interface{}
+}
+
+func (f *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var body map[string]interface{}
+	json.NewDecoder(r.Body).Decode(&body)
+	f.mu.Lock()
+	f.reqs = append(f.reqs, fakeRequest{Path: r.URL.Path, APIKey: r.Header.Get("x-api-key"), Body: body})
+	f.mu.Unlock()
+
+	switch path := r.URL.Path; {
+	case strings.HasSuffix(path, "/search"):
+		fmt.Fprint(w, `{"success":true,"tx_id":"tx-s","results":[`+
+			`{"title":"Attention Is All You Need","url":"https://arxiv.org/abs/1706.03762","source":"arxiv.org","publication_date":"2017-06-12","relevance_score":0.91,"content":"The dominant sequence transduction models."},`+
+			`{"title":"BERT | Pre-training","url":"https://arxiv.org/abs/1810.04805","source":"arxiv.org","description":"Deep bidirectional transformers."}]}`)
+	case strings.HasSuffix(path, "/contents"):
+		fmt.Fprint(w, `{"success":true,"results":[{"url":"https://example.com/a","title":"Page A","content":"Body of A.","length":9,"price":0.001,"summary":"About A."}]}`)
+	case strings.HasSuffix(path, "/datasources"):
+		fmt.Fprint(w, `{"success":true,"datasources":[`+
+			`{"id":"valyu/valyu-arxiv","name":"arXiv","category":"research","description":"Preprints.","pricing":{"cpm":0.5}},`+
+			`{"id":"valyu/valyu-stocks","name":"Stocks","category":"markets","pricing":{"cpm":1}}]}`)
+	case strings.HasSuffix(path, "/answer"):
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, `data: {"search_results":[{"title":"Attention Is All You Need","url":"https://arxiv.org/abs/1706.03762"}]}`+"\n\n")
+		fmt.Fprint(w, `data: {"choices":[{"delta":{"content":"Transformers use attention"}}]}`+"\n\n")
+		fmt.Fprint(w, `data: {"choices":[{"delta":{"content":" [1]."}}]}`+"\n\n")
+		fmt.Fprint(w, `data: {"success":true,"tx_id":"tx-a"}`+"\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (f *fakeAPI) requests() []fakeRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]fakeRequest(nil), f.reqs...)
+}
+
+// newFakeAPI starts a fakeAPI and points the environment at it, with no
+// config file in reach.
+func newFakeAPI(t *testing.T) *fakeAPI {
+	t.Helper()
+	f := &fakeAPI{}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	isolateEnv(t)
+	t.Setenv("VALYU_API_KEY", "env-key")
+	t.Setenv("VALYU_BASE_URL", srv.URL)
+	return f
+}
+
+func isolateEnv(t *testing.T) {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	t.Setenv("HOME", dir)
+	t.Setenv("VALYU_CONFIG", "")
+	t.Setenv("VALYU_API_KEY", "")
+	t.Setenv("VALYU_BASE_URL", "")
+}
+
+func runCLI(t *testing.T, stdin string, args ...string) (string, string, error) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	err := run(context.Background(), args, strings.NewReader(stdin), &stdout, &stderr)
+	return stdout.String(), stderr.String(), err
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		wantPos  []string
+		wantN    int
+		wantJSON bool
+		wantErr  bool
+	}{
+		{"flags first", []string{"-n", "3", "--json", "query"}, []string{"query"}, 3, true, false},
+		{"flags after positional", []string{"query", "-n", "3", "--json"}, []string{"query"}, 3, true, false},
+		{"interleaved", []string{"a", "-n=2", "b", "--json", "c"}, []string{"a", "b", "c"}, 2, true, false},
+		{"double dash ends flags", []string{"a", "--", "--json", "-n"}, []string{"a", "--json", "-n"}, 10, false, false},
+		{"stdin marker", []string{"-", "--json"}, []string{"-"}, 10, true, false},
+		{"no arguments", nil, nil, 10, false, false},
+		{"unknown flag", []string{"query", "--nope"}, nil, 10, false, true},
+		{"bad value", []string{"query", "-n", "many"}, nil, 10, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			fs.SetOutput(io.Discard)
+			n := fs.Int("n", 10, "")
+			asJSON := fs.Bool("json", false, "")
+
+			pos, err := parse(fs, tt.args)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parse(%q) = %q, want an error", tt.args, pos)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(pos, tt.wantPos) || *n != tt.wantN || *asJSON != tt.wantJSON {
+				t.Errorf("parse(%q) = %q, -n %d, --json %v; want %q, %d, %v", tt.args, pos, *n, *asJSON, tt.wantPos, tt.wantN, tt.wantJSON)
+			}
+		})
+	}
+}
+
+func TestOutputFormat(t *testing.T) {
+	tests := []struct {
+		flags   outputFlags
+		want    format
+		wantErr bool
+	}{
+		{outputFlags{}, formatTable, false},
+		{outputFlags{json: true}, formatJSON, false},
+		{outputFlags{jsonl: true}, formatJSONL, false},
+		{outputFlags{table: true}, formatTable, false},
+		{outputFlags{markdown: true}, formatMarkdown, false},
+		{outputFlags{json: true, jsonl: true}, "", true},
+		{outputFlags{table: true, markdown: true}, "", true},
+	}
+	for _, tt := range tests {
+		got, err := tt.flags.format()
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("%+v.format() = %q, %v; want %q, error %v", tt.flags, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	good := filepath.Join(dir, "good.json")
+	bad := filepath.Join(dir, "bad.json")
+	os.WriteFile(good, []byte(`{"api_key":"file-key","base_url":"https://file.example"}`), 0o600)
+	os.WriteFile(bad, []byte(`{"api_key":`), 0o600)
+
+	tests := []struct {
+		name    string
+		path    string
+		env     string
+		want    config
+		wantErr string
+	}{
+		{"no file at the default location", "", "", config{}, ""},
+		{"explicit path", good, "", config{APIKey: "file-key", BaseURL: "https://file.example"}, ""},
+		{"VALYU_CONFIG", "", good, config{APIKey: "file-key", BaseURL: "https://file.example"}, ""},
+		{"flag beats VALYU_CONFIG", good, bad, config{APIKey: "file-key", BaseURL: "https://file.example"}, ""},
+		{"explicit missing file", filepath.Join(dir, "missing.json"), "", config{}, "missing.json"},
+		{"missing VALYU_CONFIG", "", filepath.Join(dir, "missing.json"), config{}, "missing.json"},
+		{"invalid JSON", bad, "", config{}, "config " + bad},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolateEnv(t)
+			t.Setenv("VALYU_CONFIG", tt.env)
+			got, err := loadConfig(tt.path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("loadConfig = %v, want an error mentioning %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("loadConfig = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("default location", func(t *testing.T) {
+		isolateEnv(t)
+		cfgDir, err := os.UserConfigDir()
+		if err != nil {
+			t.Skip(err)
+		}
+		os.MkdirAll(filepath.Join(cfgDir, "valyu"), 0o700)
+		os.WriteFile(filepath.Join(cfgDir, "valyu", "config.json"), []byte(`{"api_key":"default-key"}`), 0o600)
+		if got, err := loadConfig(""); err != nil || got.APIKey != "default-key" {
+			t.Errorf("loadConfig = %+v, %v; want the default config file", got, err)
+		}
+	})
+}
+
+func TestClientPrecedence(t *testing.T) {
+	f := &fakeAPI{}
+	srv := httptest.NewServer(f)
+	defer srv.Close()
+
+	cfgPath := filepath.Join(t.TempDir(), "config.json")
+	os.WriteFile(cfgPath, []byte(fmt.Sprintf(`{"api_key":"file-key","base_url":%q}`, srv.URL+"/file")), 0o600)
+
+	tests := []struct {
+		name     string
+		env      map[string]string
+		flags    []string
+		wantPath string
+		wantKey  string
+		wantErr  string
+	}{
+		{
+			name:     "config file",
+			env:      map[string]string{"VALYU_CONFIG": cfgPath},
+			wantPath: "/file/search",
+			wantKey:  "file-key",
+		},
+		{
+			name:     "environment over config file",
+			env:      map[string]string{"VALYU_CONFIG": cfgPath, "VALYU_API_KEY": "env-key", "VALYU_BASE_URL": srv.URL + "/env"},
+			wantPath: "/env/search",
+			wantKey:  "env-key",
+		},
+		{
+			name:     "flags over environment",
+			env:      map[string]string{"VALYU_CONFIG": cfgPath, "VALYU_API_KEY": "env-key", "VALYU_BASE_URL": srv.URL + "/env"},
+			flags:    []string{"--api-key", "flag-key", "--base-url", srv.URL + "/flag"},
+			wantPath: "/flag/search",
+			wantKey:  "flag-key",
+		},
+		{
+			name:     "mixed sources",
+			env:      map[string]string{"VALYU_API_KEY": "env-key"},
+			flags:    []string{"--config", cfgPath},
+			wantPath: "/file/search",
+			wantKey:  "env-key",
+		},
+		{
+			name:    "no key",
+			wantErr: "no API key",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolateEnv(t)
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			before := len(f.requests())
+
+			args := append([]string{"search", "q", "--jsonl"}, tt.flags...)
+			_, _, err := runCLI(t, "", args...)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("run = %v, want an error mentioning %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			reqs := f.requests()[before:]
+			if len(reqs) != 1 || reqs[0].Path != tt.wantPath || reqs[0].APIKey != tt.wantKey {
+				t.Errorf("requests = %+v, want one to %s with key %s", reqs, tt.wantPath, tt.wantKey)
+			}
+		})
+	}
+}
+
+func TestSearchCommand(t *testing.T) {
+	f := newFakeAPI(t)
+
+	stdout, _, err := runCLI(t, "", "search", "attention", "models", "-n", "2", "--jsonl", "--sources", "arxiv.org,valyu/valyu-arxiv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := f.requests()[0]
+	if req.Body["query"] != "attention models" || req.Body["max_num_results"] != float64(2) ||
+		!reflect.DeepEqual(req.Body["included_sources"], []interface{}{"arxiv.org", "valyu/valyu-arxiv"}) {
+		t.Errorf("request body = %v", req.Body)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(stdout, "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("--jsonl printed %d lines, want one per result:\n%s", len(lines), stdout)
+	}
+	for i, want := range []string{"https://arxiv.org/abs/1706.03762", "https://arxiv.org/abs/1810.04805"} {
+		var r struct {
+			URL string `json:"url"`
+		}
+		if err := json.Unmarshal([]byte(lines[i]), &r); err != nil || r.URL != want {
+			t.Errorf("line %d = %s, want a result with url %s", i, lines[i], want)
+		}
+	}
+
+	stdout, _, err = runCLI(t, "", "search", "attention", "--markdown")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "1. [Attention Is All You Need](https://arxiv.org/abs/1706.03762) — arxiv.org, 2017-06-12\n" +
+		"   > The dominant sequence transduction models.\n" +
+		"2. [BERT | Pre-training](https://arxiv.org/abs/1810.04805) — arxiv.org\n" +
+		"   > Deep bidirectional transformers.\n"
+	if stdout != want {
+		t.Errorf("--markdown =\n%s\nwant\n%s", stdout, want)
+	}
+
+	stdout, _, err = runCLI(t, "", "search", "attention")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(stdout, "#  TITLE") || !strings.Contains(stdout, "0.91") {
+		t.Errorf("table =\n%s", stdout)
+	}
+
+	stdout, _, err = runCLI(t, "", "search", "attention", "--json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var resp struct {
+		TxID string `json:"tx_id"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &resp); err != nil || resp.TxID != "tx-s" || !strings.Contains(stdout, "\n  ") {
+		t.Errorf("--json = %s, want the indented response", stdout)
+	}
+}
+
+func TestSearchQueryFromStdin(t *testing.T) {
+	f := newFakeAPI(t)
+	if _, _, err := runCLI(t, "  piped query\n", "search", "-", "--jsonl"); err != nil {
+		t.Fatal(err)
+	}
+	if got := f.requests()[0].Body["query"]; got != "piped query" {
+		t.Errorf("query = %v, want the trimmed stdin", got)
+	}
+	if _, _, err := runCLI(t, "", "search", "--jsonl"); err == nil || !strings.Contains(err.Error(), "query is empty") {
+		t.Errorf("empty stdin = %v, want an empty query error", err)
+	}
+}
+
+func TestContentsMarkdown(t *testing.T) {
+	newFakeAPI(t)
+	stdout, _, err := runCLI(t, "", "contents", "https://example.com/a", "--markdown", "--summary", "true")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "# Page A\n\n<https://example.com/a>\n\nAbout A.\n\nBody of A.\n"
+	if stdout != want {
+		t.Errorf("--markdown =\n%s\nwant\n%s", stdout, want)
+	}
+}
+
+func TestDatasourcesCategory(t *testing.T) {
+	newFakeAPI(t)
+	stdout, _, err := runCLI(t, "", "datasources", "--category", "Markets", "--jsonl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(stdout), "\n"); len(lines) != 1 || !strings.Contains(lines[0], `"id":"valyu/valyu-stocks"`) {
+		t.Errorf("--jsonl =\n%s\nwant only the markets dataset", stdout)
+	}
+
+	stdout, _, err = runCLI(t, "", "datasources", "--markdown")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(stdout, "## arXiv\n\n`valyu/valyu-arxiv` · research · $0.50 per 1k results\n\nPreprints.\n") {
+		t.Errorf("--markdown =\n%s", stdout)
+	}
+}
+
+func TestAnswerCommand(t *testing.T) {
+	newFakeAPI(t)
+
+	stdout, _, err := runCLI(t, "", "answer", "how do transformers work", "--markdown")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Transformers use attention [^1].\n\n[^1]: [Attention Is All You Need](https://arxiv.org/abs/1706.03762)\n"
+	if stdout != want {
+		t.Errorf("--markdown =\n%s\nwant\n%s", stdout, want)
+	}
+
+	stdout, _, err = runCLI(t, "", "answer", "how do transformers work", "--jsonl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var types []string
+	for _, line := range strings.Split(strings.TrimSpace(stdout), "\n") {
+		var chunk struct {
+			Type string `json:"type"`
+		}
+		if err := json.Unmarshal([]byte(line), &chunk); err != nil {
+			t.Fatalf("line %q: %v", line, err)
+		}
+		types = append(types, chunk.Type)
+	}
+	if want := []string{"search_results", "content", "content", "metadata", "done"}; !reflect.DeepEqual(types, want) {
+		t.Errorf("--jsonl chunk types = %q, want %q", types, want)
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	newFakeAPI(t)
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"search", "q", "--json", "--jsonl"}, "mutually exclusive"},
+		{[]string{"contents", "--jsonl"}, "at least one URL"},
+		{[]string{"nope"}, `unknown command "nope"`},
+		{[]string{"deepresearch"}, "usage: valyu deepresearch"},
+	}
+	for _, tt := range tests {
+		if _, _, err := runCLI(t, "", tt.args...); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("run(%q) = %v, want an error mentioning %q", tt.args, err, tt.want)
+		}
+	}
+}
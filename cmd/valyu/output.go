@@ -0,0 +1,173 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"unicode/utf8"
+)
+
+type format string
+
+const (
+	formatTable    format = "table"
+	formatJSON     format = "json"
+	formatJSONL    format = "jsonl"
+	formatMarkdown format = "markdown"
+)
+
+// outputFlags are the --json, --jsonl, --table and --markdown switches.
+type outputFlags struct {
+	json, jsonl, table, markdown bool
+}
+
+func (f *outputFlags) register(fs *flag.FlagSet) {
+	fs.BoolVar(&f.json, "json", false, "print the full response as indented JSON")
+	fs.BoolVar(&f.jsonl, "jsonl", false, "print one JSON object per line")
+	fs.BoolVar(&f.table, "table", false, "print aligned columns (default)")
+	fs.BoolVar(&f.markdown, "markdown", false, "print Markdown")
+}
+
+func (f *outputFlags) format() (format, error) {
+	var set []format
+	if f.json {
+		set = append(set, formatJSON)
+	}
+	if f.jsonl {
+		set = append(set, formatJSONL)
+	}
+	if f.table {
+		set = append(set, formatTable)
+	}
+	if f.markdown {
+		set = append(set, formatMarkdown)
+	}
+	switch len(set) {
+	case 0:
+		return formatTable, nil
+	case 1:
+		return set[0], nil
+	}
+	return "", errors.New("--json, --jsonl, --table and --markdown are mutually exclusive")
+}
+
+// view is what a command prints, in every format.
+type view struct {
+	// raw is printed by --json.
+	raw interface{}
+	// records are printed one per line by --jsonl; nil means raw.
+	records []interface{}
+	// columns and rows are printed by --table, and by --markdown when
+	// markdown is nil.
+	columns []string
+	rows    [][]string
+	// markdown writes the --markdown form.
+	markdown func(w io.Writer)
+}
+
+func printView(w io.Writer, f format, v view) error {
+	switch f {
+	case formatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.SetEscapeHTML(false)
+		return enc.Encode(v.raw)
+	case formatJSONL:
+		records := v.records
+		if records == nil {
+			records = []interface{}{v.raw}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+		for _, r := range records {
+			if err := enc.Encode(r); err != nil {
+				return err
+			}
+		}
+		return nil
+	case formatMarkdown:
+		if v.markdown != nil {
+			v.markdown(w)
+			return nil
+		}
+		return writeMarkdownTable(w, v.columns, v.rows)
+	}
+	return writeTable(w, v.columns, v.rows)
+}
+
+const maxCellWidth = 60
+
+func writeTable(w io.Writer, columns []string, rows [][]string) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(columns, "\t"))
+	for _, row := range rows {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			cells[i] = ellipsize(oneLine(cell), maxCellWidth)
+		}
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+	return tw.Flush()
+}
+
+func writeMarkdownTable(w io.Writer, columns []string, rows [][]string) error {
+	escape := strings.NewReplacer("|", `\|`)
+	fmt.Fprintf(w, "| %s |\n", strings.Join(columns, " | "))
+	fmt.Fprintf(w, "|%s\n", strings.Repeat(" --- |", len(columns)))
+	for _, row := range rows {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			cells[i] = escape.Replace(oneLine(cell))
+		}
+		if _, err := fmt.Fprintf(w, "| %s |\n", strings.Join(cells, " | ")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func ellipsize(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	r := []rune(s)
+	return string(r[:n-1]) + "…"
+}
+
+// text renders API content fields, which are strings or arbitrary JSON.
+func text(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	}
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+func records[T any](items []T) []interface{} {
+	out := make([]interface{}, len(items))
+	for i := range items {
+		out[i] = items[i]
+	}
+	return out
+}
+
+func dollars(f float64) string {
+	if f == 0 {
+		return ""
+	}
+	return fmt.Sprintf("$%.4f", f)
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/Veri5ied/valyu-go/valyu/common"
+	"github.com/Veri5ied/valyu-go/valyu/search"
+)
+
+func runSearch(e *env, args []string) error {
+	fs := newFlagSet(e, "search", "<query>")
+	var (
+		cf    clientFlags
+		of    outputFlags
+		opts  search.Options
+		kind  string
+		incl  listFlag
+		excl  listFlag
+		limit int
+	)
+	cf.register(fs)
+	of.register(fs)
+	fs.IntVar(&limit, "n", 10, "maximum number of results")
+	fs.StringVar(&kind, "type", "", "search type: all, web, proprietary or news")
+	fs.Var(&incl, "sources", "comma-separated domains and dataset IDs to search")
+	fs.Var(&excl, "exclude", "comma-separated domains and dataset IDs to skip")
+	fs.Float64Var(&opts.RelevanceThreshold, "relevance", 0, "minimum relevance score, 0 to 1")
+	fs.Float64Var(&opts.MaxPrice, "max-price", 0, "maximum price in dollars per thousand retrievals")
+	fs.StringVar(&opts.Category, "category", "", "natural-language category to focus on")
+	fs.StringVar(&opts.StartDate, "start", "", "earliest publication date, YYYY-MM-DD")
+	fs.StringVar(&opts.EndDate, "end", "", "latest publication date, YYYY-MM-DD")
+	fs.BoolVar(&opts.FastMode, "fast", false, "trade result quality for latency")
+	pos, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	f, err := of.format()
+	if err != nil {
+		return err
+	}
+	q, err := query(e, pos)
+	if err != nil {
+		return err
+	}
+	client, err := cf.client(e)
+	if err != nil {
+		return err
+	}
+
+	opts.MaxNumResults = limit
+	opts.SearchType = common.SearchType(kind)
+	opts.IncludedSources = incl
+	opts.ExcludeSources = excl
+	resp, err := client.Search.Search(e.ctx, q, &opts)
+	if err != nil {
+		return err
+	}
+
+	v := view{
+		raw:     resp,
+		records: records(resp.Results),
+		columns: []string{"#", "TITLE", "SOURCE", "DATE", "RELEVANCE", "URL"},
+	}
+	for i, r := range resp.Results {
+		v.rows = append(v.rows, []string{
+			strconv.Itoa(i + 1),
+			r.Title,
+			r.Source,
+			first(r.PublicationDate, r.Date),
+			relevance(r.RelevanceScore),
+			r.URL,
+		})
+	}
+	v.markdown = func(w io.Writer) {
+		for i, r := range resp.Results {
+			fmt.Fprintf(w, "%d. [%s](%s)", i+1, r.Title, r.URL)
+			if date := first(r.PublicationDate, r.Date); date != "" {
+				fmt.Fprintf(w, " — %s, %s", r.Source, date)
+			} else if r.Source != "" {
+				fmt.Fprintf(w, " — %s", r.Source)
+			}
+			fmt.Fprintln(w)
+			if s := oneLine(first(r.Description, text(r.Content))); s != "" {
+				fmt.Fprintf(w, "   > %s\n", ellipsize(s, 300))
+			}
+		}
+	}
+	return printView(e.stdout, f, v)
+}
+
+func relevance(score float64) string {
+	if score == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(score, 'f', 2, 64)
+}
@@ -11,7 +11,9 @@ import (
 
 	"github.com/Veri5ied/valyu-go/valyu"
 	"github.com/Veri5ied/valyu-go/valyu/answer"
+	"github.com/Veri5ied/valyu-go/valyu/answerhttp"
 	"github.com/Veri5ied/valyu-go/valyu/common"
+	"github.com/Veri5ied/valyu-go/valyu/option"
 	"github.com/Veri5ied/valyu-go/valyu/search"
 )
 
@@ -56,6 +58,17 @@ type PaperResponse struct {
 func main() {
 	defaultApiKey := os.Getenv("VALYU_API_KEY")
 
+	// Each request passes the caller's key with option.WithAPIKey, so the
+	// client-level key is only a placeholder when VALYU_API_KEY is unset.
+	clientKey := defaultApiKey
+	if clientKey == "" {
+		clientKey = "per-request"
+	}
+	client, err := valyu.New(clientKey)
+	if err != nil {
+		log.Fatalf("failed to create valyu client: %v", err)
+	}
+
 	http.HandleFunc("/search", func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -79,13 +92,6 @@ func main() {
 			return
 		}
 
-		client, err := valyu.New(apiKey)
-		if err != nil {
-			log.Printf("failed to create valyu client: %v", err)
-			http.Error(w, "Failed to initialize SDK", http.StatusInternalServerError)
-			return
-		}
-
 		log.Printf("Searching academic papers: %s", req.Query)
 
 		maxResults := req.MaxResults
@@ -100,19 +106,18 @@ func main() {
 			SearchType:      common.SearchTypeProprietary,
 			MaxNumResults:   maxResults,
 			IncludedSources: []string{"valyu/valyu-arxiv"},
-		})
+		}, option.WithAPIKey(apiKey))
+		if respErr, ok := valyu.AsResponseError(err); ok {
+			log.Printf("API error: %s", respErr.Message)
+			http.Error(w, fmt.Sprintf("API error: %s", respErr.Message), http.StatusBadGateway)
+			return
+		}
 		if err != nil {
 			log.Printf("SDK error: %v", err)
 			http.Error(w, "Failed to search papers", http.StatusInternalServerError)
 			return
 		}
 
-		if !searchResp.Success {
-			log.Printf("API error: %s", searchResp.Error)
-			http.Error(w, fmt.Sprintf("API error: %s", searchResp.Error), http.StatusBadGateway)
-			return
-		}
-
 		results := make([]SearchResult, 0, len(searchResp.Results))
 		for _, r := range searchResp.Results {
 			results = append(results, SearchResult{
@@ -156,13 +161,6 @@ func main() {
 			return
 		}
 
-		client, err := valyu.New(apiKey)
-		if err != nil {
-			log.Printf("failed to create valyu client: %v", err)
-			http.Error(w, "Failed to initialize SDK", http.StatusInternalServerError)
-			return
-		}
-
 		log.Printf("Summarizing research topic: %s", req.Topic)
 
 		prompt := fmt.Sprintf("Provide a comprehensive academic summary of recent research on '%s'. Include key findings, major contributors, current debates, and future research directions. Focus on peer-reviewed sources.", req.Topic)
@@ -172,19 +170,18 @@ func main() {
 
 		ans, err := client.Answer.Answer(ctx, prompt, &answer.Options{
 			SearchType: common.SearchTypeProprietary,
-		})
+		}, option.WithAPIKey(apiKey))
+		if respErr, ok := valyu.AsResponseError(err); ok {
+			log.Printf("API error: %s", respErr.Message)
+			http.Error(w, fmt.Sprintf("API error: %s", respErr.Message), http.StatusBadGateway)
+			return
+		}
 		if err != nil {
 			log.Printf("SDK error: %v", err)
 			http.Error(w, "Failed to generate summary", http.StatusInternalServerError)
 			return
 		}
 
-		if !ans.Success {
-			log.Printf("API error: %s", ans.Error)
-			http.Error(w, fmt.Sprintf("API error: %s", ans.Error), http.StatusBadGateway)
-			return
-		}
-
 		resp := SummarizeResponse{
 			Topic:   req.Topic,
 			Summary: ans.Contents,
@@ -195,6 +192,25 @@ func main() {
 		json.NewEncoder(w).Encode(resp)
 	})
 
+	// GET /summarize/stream?topic=... streams the summary as server-sent
+	// events for use with EventSource.
+	http.Handle("/summarize/stream", answerhttp.NewHandler(client.Answer,
+		answerhttp.WithRequestParser(func(r *http.Request) (string, *answer.Options, error) {
+			topic := r.URL.Query().Get("topic")
+			if topic == "" {
+				return "", nil, fmt.Errorf("topic is required")
+			}
+			prompt := fmt.Sprintf("Provide a comprehensive academic summary of recent research on '%s'. Include key findings, major contributors, current debates, and future research directions. Focus on peer-reviewed sources.", topic)
+			return prompt, &answer.Options{SearchType: common.SearchTypeProprietary}, nil
+		}),
+		answerhttp.WithRequestOptions(func(r *http.Request) []option.RequestOption {
+			if apiKey := getAPIKey(r, defaultApiKey); apiKey != "" {
+				return []option.RequestOption{option.WithAPIKey(apiKey)}
+			}
+			return nil
+		}),
+	))
+
 	http.HandleFunc("/papers", func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -218,13 +234,6 @@ func main() {
 			return
 		}
 
-		client, err := valyu.New(apiKey)
-		if err != nil {
-			log.Printf("failed to create valyu client: %v", err)
-			http.Error(w, "Failed to initialize SDK", http.StatusInternalServerError)
-			return
-		}
-
 		log.Printf("Analyzing paper: %s", req.URL)
 
 		prompt := fmt.Sprintf("Analyze this research paper: %s. Provide: 1) Main hypothesis and research question, 2) Methodology used, 3) Key findings and contributions, 4) Limitations and critiques, 5) Implications for future research.", req.URL)
@@ -234,19 +243,18 @@ func main() {
 
 		ans, err := client.Answer.Answer(ctx, prompt, &answer.Options{
 			SearchType: common.SearchTypeAll,
-		})
+		}, option.WithAPIKey(apiKey))
+		if respErr, ok := valyu.AsResponseError(err); ok {
+			log.Printf("API error: %s", respErr.Message)
+			http.Error(w, fmt.Sprintf("API error: %s", respErr.Message), http.StatusBadGateway)
+			return
+		}
 		if err != nil {
 			log.Printf("SDK error: %v", err)
 			http.Error(w, "Failed to analyze paper", http.StatusInternalServerError)
 			return
 		}
 
-		if !ans.Success {
-			log.Printf("API error: %s", ans.Error)
-			http.Error(w, fmt.Sprintf("API error: %s", ans.Error), http.StatusBadGateway)
-			return
-		}
-
 		resp := PaperResponse{
 			URL:      req.URL,
 			Analysis: ans.Contents,
@@ -271,6 +279,7 @@ func main() {
 	log.Printf("Available endpoints:")
 	log.Printf("  POST /search - Search academic papers")
 	log.Printf("  POST /summarize - Summarize research topics")
+	log.Printf("  GET  /summarize/stream - Stream a summary as server-sent events")
 	log.Printf("  POST /papers - Analyze specific papers")
 	log.Printf("  GET  /health - Health check")
 
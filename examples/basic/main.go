@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/Veri5ied/valyu-go/valyu"
+	"github.com/Veri5ied/valyu-go/valyu/answer"
 	"github.com/Veri5ied/valyu-go/valyu/search"
 )
 
@@ -39,8 +40,6 @@ func main() {
 	sr, err := client.Search.Search(ctx, "What is retrieval-augmented generation?", &search.Options{MaxNumResults: 3})
 	if err != nil {
 		fmt.Println("Search error:", err)
-	} else if !sr.Success {
-		fmt.Println("Search API error:", sr.Error)
 	} else {
 		for i, r := range sr.Results {
 			fmt.Printf("%d) %s — %s\n", i+1, r.Title, r.URL)
@@ -52,8 +51,6 @@ func main() {
 	ans, err := client.Answer.Answer(ctx, "Explain RAG in AI, briefly.", nil)
 	if err != nil {
 		fmt.Println("Answer error:", err)
-	} else if !ans.Success {
-		fmt.Println("Answer API error:", ans.Error)
 	} else {
 		fmt.Println("Answer contents:", pretty(ans.Contents))
 		fmt.Printf("Found %d sources\n", len(ans.SearchResults))
@@ -70,19 +67,19 @@ func main() {
 
 		for chunk := range streamCh {
 			switch chunk.Type {
-			case "error":
+			case answer.ChunkError:
 				fmt.Printf("Stream error: %s\n", chunk.Error)
-			case "search_results":
+			case answer.ChunkSearchResults:
 				streamResults = append(streamResults, chunk.SearchResults...)
 				fmt.Printf("Got %d search results\n", len(chunk.SearchResults))
-			case "content":
+			case answer.ChunkContent:
 				fullContent.WriteString(chunk.Content)
 				if chunk.Content != "" {
 					fmt.Print(chunk.Content)
 				}
-			case "metadata":
+			case answer.ChunkMetadata:
 				fmt.Printf("\nStream metadata - TxID: %s\n", chunk.TxID)
-			case "done":
+			case answer.ChunkDone:
 				fmt.Println("\n[Stream completed]")
 			}
 		}
@@ -96,8 +93,6 @@ func main() {
 	ds, err := client.Datasources.List(ctx)
 	if err != nil {
 		fmt.Println("Datasources error:", err)
-	} else if !ds.Success {
-		fmt.Println("Datasources API error:", ds.Error)
 	} else {
 		fmt.Printf("Available datasources: %d\n", len(ds.Datasources))
 	}
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/Veri5ied/valyu-go/valyu"
+	"github.com/Veri5ied/valyu-go/valyu/option"
 )
 
 type AnalysisRequest struct {
@@ -25,6 +26,17 @@ type AnalysisResponse struct {
 func main() {
 	defaultApiKey := os.Getenv("VALYU_API_KEY")
 
+	// Each request passes the caller's key with option.WithAPIKey, so the
+	// client-level key is only a placeholder when VALYU_API_KEY is unset.
+	clientKey := defaultApiKey
+	if clientKey == "" {
+		clientKey = "per-request"
+	}
+	client, err := valyu.New(clientKey)
+	if err != nil {
+		log.Fatalf("failed to create valyu client: %v", err)
+	}
+
 	http.HandleFunc("/analyze", func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -52,13 +64,6 @@ func main() {
 			return
 		}
 
-		client, err := valyu.New(apiKey)
-		if err != nil {
-			log.Printf("failed to create valyu client: %v", err)
-			http.Error(w, "Failed to initialize SDK", http.StatusInternalServerError)
-			return
-		}
-
 		log.Printf("Analyzing: %s", req.Query)
 
 		// Prompt for market analysis
@@ -67,19 +72,18 @@ func main() {
 		ctx, cancel := context.WithTimeout(r.Context(), 60*time.Second)
 		defer cancel()
 
-		ans, err := client.Answer.Answer(ctx, prompt, nil)
+		ans, err := client.Answer.Answer(ctx, prompt, nil, option.WithAPIKey(apiKey))
+		if respErr, ok := valyu.AsResponseError(err); ok {
+			log.Printf("API error: %s", respErr.Message)
+			http.Error(w, fmt.Sprintf("API error: %s", respErr.Message), http.StatusBadGateway)
+			return
+		}
 		if err != nil {
 			log.Printf("SDK error: %v", err)
 			http.Error(w, "Failed to analyze market", http.StatusInternalServerError)
 			return
 		}
 
-		if !ans.Success {
-			log.Printf("API error: %s", ans.Error)
-			http.Error(w, fmt.Sprintf("API error: %s", ans.Error), http.StatusBadGateway)
-			return
-		}
-
 		resp := AnalysisResponse{
 			Company:  req.Query,
 			Analysis: ans.Contents,
@@ -1,12 +1,14 @@
 package answer
 
 import (
-	"bufio"
 	"context"
 	"encoding/json"
+	"io"
 	"strings"
 
+	"github.com/Veri5ied/valyu-go/valyu/common"
 	"github.com/Veri5ied/valyu-go/valyu/internal/api"
+	"github.com/Veri5ied/valyu-go/valyu/option"
 	"github.com/Veri5ied/valyu-go/valyu/search"
 )
 
@@ -18,158 +20,180 @@ func New(client *api.Client) *Service {
 	return &Service{client: client}
 }
 
-func (s *Service) Answer(ctx context.Context, query string, opts *Options) (*Response, error) {
-	streamCh, err := s.Stream(ctx, query, opts)
+func (s *Service) Answer(ctx context.Context, query string, opts *Options, reqOpts ...option.RequestOption) (*Response, error) {
+	return s.StreamWith(ctx, query, opts, HandlerFuncs{}, reqOpts...)
+}
+
+func responseError(resp *Response) error {
+	return &common.ResponseError{
+		Message:  resp.Error,
+		TxID:     resp.TxID,
+		Response: resp,
+	}
+}
+
+// Stream delivers the answer as chunks on a channel that is closed when the
+// stream ends. If the stream breaks, a final chunk of type "error" carries
+// the cause in Err. The goroutine feeding the channel exits once ctx is
+// cancelled, so callers that stop reading early must cancel ctx.
+func (s *Service) Stream(ctx context.Context, query string, opts *Options, reqOpts ...option.RequestOption) (<-chan StreamChunk, error) {
+	st, err := s.OpenStream(ctx, query, opts, reqOpts...)
 	if err != nil {
 		return nil, err
 	}
 
-	finalResp := &Response{
-		Success: true,
-	}
-	var fullContent strings.Builder
+	ch := make(chan StreamChunk, 100)
+	go func() {
+		defer close(ch)
+		defer st.Close()
 
-	for chunk := range streamCh {
-		if chunk.Error != "" {
-			finalResp.Error = chunk.Error
-			finalResp.Success = false
-		}
-		if chunk.Type == "search_results" {
-			finalResp.SearchResults = append(finalResp.SearchResults, chunk.SearchResults...)
-		}
-		if chunk.Type == "content" {
-			fullContent.WriteString(chunk.Content)
-		}
-		if chunk.Type == "metadata" {
-			if chunk.TxID != "" {
-				finalResp.TxID = chunk.TxID
-			}
-			if chunk.OriginalQuery != "" {
-				finalResp.OriginalQuery = chunk.OriginalQuery
-			}
-			if chunk.SearchMetadata != nil {
-				finalResp.SearchMetadata = *chunk.SearchMetadata
-			}
-			if chunk.AIUsage != nil {
-				finalResp.AIUsage = *chunk.AIUsage
-			}
-			if chunk.Cost != nil {
-				finalResp.Cost = *chunk.Cost
+		for st.Next() {
+			select {
+			case ch <- st.Chunk():
+			case <-ctx.Done():
+				return
 			}
-			if chunk.DataType != "" {
-				finalResp.DataType = chunk.DataType
+		}
+		if err := st.Err(); err != nil && ctx.Err() == nil {
+			select {
+			case ch <- StreamChunk{Type: ChunkError, Error: err.Error(), Err: err}:
+			case <-ctx.Done():
 			}
 		}
-	}
-
-	finalResp.Contents = fullContent.String()
-	if finalResp.Error == "" {
-		finalResp.Success = true
-	}
+	}()
 
-	return finalResp, nil
+	return ch, nil
 }
 
-func (s *Service) Stream(ctx context.Context, query string, opts *Options) (<-chan StreamChunk, error) {
-	var reqOpts Options
+// OpenStream starts an answer and returns a ChunkStream to read it. The
+// caller must Close it, or cancel ctx, to release the connection.
+func (s *Service) OpenStream(ctx context.Context, query string, opts *Options, reqOpts ...option.RequestOption) (*ChunkStream, error) {
+	var bodyOpts Options
 	if opts != nil {
-		reqOpts = *opts
+		bodyOpts = *opts
 	}
-	if reqOpts.SearchType == "" {
-		reqOpts.SearchType = "all"
+	if bodyOpts.SearchType == "" {
+		bodyOpts.SearchType = "all"
 	}
 
-	req := struct {
-		Query string `json:"query"`
-		Options
-	}{
+	req := Request{
 		Query:   query,
-		Options: reqOpts,
+		Options: bodyOpts,
 	}
 
-	resp, err := s.client.PostStream(ctx, "/answer", req)
+	resp, err := s.client.PostStream(ctx, "/answer", req, reqOpts...)
 	if err != nil {
 		return nil, err
 	}
 
-	if resp.StatusCode >= 400 {
-		defer resp.Body.Close()
-		var errResp struct {
-			Error string `json:"error"`
-		}
-		_ = json.NewDecoder(resp.Body).Decode(&errResp)
-		if errResp.Error != "" {
-			return nil, &api.APIError{StatusCode: resp.StatusCode, Message: errResp.Error}
+	reconnect := func(ctx context.Context, lastEventID string) (io.ReadCloser, error) {
+		opts := append(append([]option.RequestOption(nil), reqOpts...), option.WithHeader("Last-Event-ID", lastEventID))
+		resp, err := s.client.PostStream(ctx, "/answer", req, opts...)
+		if err != nil {
+			return nil, err
 		}
-		return nil, &api.APIError{StatusCode: resp.StatusCode, Message: "Request failed"}
+		return resp.Body, nil
 	}
 
-	ch := make(chan StreamChunk, 100)
-	go func() {
-		defer close(ch)
-		defer resp.Body.Close()
+	maxReconnects := bodyOpts.MaxReconnects
+	if maxReconnects == 0 {
+		maxReconnects = DefaultMaxReconnects
+	}
 
-		scanner := bufio.NewScanner(resp.Body)
-		for scanner.Scan() {
-			line := scanner.Text()
-			if !strings.HasPrefix(line, "data: ") {
-				continue
-			}
+	return newChunkStream(ctx, resp.Body, reconnect, maxReconnects), nil
+}
 
-			dataStr := strings.TrimPrefix(line, "data: ")
-			if dataStr == "[DONE]" {
-				ch <- StreamChunk{Type: "done"}
-				return
-			}
+// aggregator folds stream chunks into a Response.
+type aggregator struct {
+	resp    Response
+	content strings.Builder
+}
 
-			var parsed map[string]interface{}
-			if err := json.Unmarshal([]byte(dataStr), &parsed); err != nil {
-				continue
-			}
+func (a *aggregator) add(chunk StreamChunk) {
+	if chunk.Error != "" {
+		a.resp.Error = chunk.Error
+	}
+	switch chunk.Type {
+	case ChunkSearchResults:
+		a.resp.SearchResults = append(a.resp.SearchResults, chunk.SearchResults...)
+	case ChunkContent:
+		a.content.WriteString(chunk.Content)
+	case ChunkMetadata:
+		if chunk.TxID != "" {
+			a.resp.TxID = chunk.TxID
+		}
+		if chunk.OriginalQuery != "" {
+			a.resp.OriginalQuery = chunk.OriginalQuery
+		}
+		if chunk.SearchMetadata != nil {
+			a.resp.SearchMetadata = *chunk.SearchMetadata
+		}
+		if chunk.AIUsage != nil {
+			a.resp.AIUsage = *chunk.AIUsage
+		}
+		if chunk.Cost != nil {
+			a.resp.Cost = *chunk.Cost
+		}
+		if chunk.DataType != "" {
+			a.resp.DataType = chunk.DataType
+		}
+	}
+}
 
-			var chunk StreamChunk
-			_ = json.Unmarshal([]byte(dataStr), &chunk)
+func (a *aggregator) response() *Response {
+	resp := a.resp
+	resp.Contents = a.content.String()
+	resp.Success = resp.Error == ""
+	return &resp
+}
 
-			if errMsg, ok := parsed["error"].(string); ok && errMsg != "" {
-				chunk.Type = "error"
-				chunk.Error = errMsg
-			} else if results, ok := parsed["search_results"]; ok {
-				chunk.Type = "search_results"
-				if len(chunk.SearchResults) == 0 {
-					if b, err := json.Marshal(results); err == nil {
-						var sr []search.Result
-						if err := json.Unmarshal(b, &sr); err == nil {
-							chunk.SearchResults = sr
-						}
-					}
-				}
-			} else if choices, ok := parsed["choices"].([]interface{}); ok && len(choices) > 0 {
-				chunk.Type = "content"
-				if choice, ok := choices[0].(map[string]interface{}); ok {
-					if delta, ok := choice["delta"].(map[string]interface{}); ok {
-						if content, ok := delta["content"].(string); ok {
-							chunk.Content = content
-						}
-					}
-					if fr, ok := choice["finish_reason"].(string); ok {
-						chunk.FinishReason = fr
-					}
+// ParseChunk decodes the data of one /answer stream event the way
+// ChunkStream does, for middleware that watches the raw stream. It reports
+// false for payloads that are not JSON or not a recognised chunk; in-band
+// failures, {"error":...} or {"success":false,...}, come back as ChunkError.
+func ParseChunk(data string) (StreamChunk, bool) {
+	var parsed map[string]interface{}
+	if err := json.Unmarshal([]byte(data), &parsed); err != nil {
+		return StreamChunk{}, false
+	}
+
+	var chunk StreamChunk
+	_ = json.Unmarshal([]byte(data), &chunk)
+
+	if errMsg, ok := parsed["error"].(string); ok && errMsg != "" {
+		chunk.Type = ChunkError
+		chunk.Error = errMsg
+	} else if results, ok := parsed["search_results"]; ok {
+		chunk.Type = ChunkSearchResults
+		if len(chunk.SearchResults) == 0 {
+			if b, err := json.Marshal(results); err == nil {
+				var sr []search.Result
+				if err := json.Unmarshal(b, &sr); err == nil {
+					chunk.SearchResults = sr
 				}
-			} else if success, ok := parsed["success"]; ok {
-				chunk.Type = "metadata"
-				if successBool, ok := success.(bool); ok && !successBool {
-					chunk.Type = "error"
-					if errMsg, ok := parsed["error"].(string); ok {
-						chunk.Error = errMsg
-					}
+			}
+		}
+	} else if choices, ok := parsed["choices"].([]interface{}); ok && len(choices) > 0 {
+		chunk.Type = ChunkContent
+		if choice, ok := choices[0].(map[string]interface{}); ok {
+			if delta, ok := choice["delta"].(map[string]interface{}); ok {
+				if content, ok := delta["content"].(string); ok {
+					chunk.Content = content
 				}
 			}
-
-			if chunk.Type != "" {
-				ch <- chunk
+			if fr, ok := choice["finish_reason"].(string); ok {
+				chunk.FinishReason = fr
 			}
 		}
-	}()
+	} else if success, ok := parsed["success"]; ok {
+		chunk.Type = ChunkMetadata
+		if successBool, ok := success.(bool); ok && !successBool {
+			chunk.Type = ChunkError
+			if errMsg, ok := parsed["error"].(string); ok {
+				chunk.Error = errMsg
+			}
+		}
+	}
 
-	return ch, nil
+	return chunk, chunk.Type != ""
 }
@@ -0,0 +1,9 @@
+package answer
+
+import "github.com/Veri5ied/valyu-go/valyu/citation"
+
+// Citations links the answer text to the search results it cites. See the
+// citation package for rendering and auditing helpers.
+func (r *Response) Citations() *citation.Document {
+	return citation.Parse(contentsText(r.Contents), r.SearchResults)
+}
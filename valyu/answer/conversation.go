@@ -0,0 +1,225 @@
+package answer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Veri5ied/valyu-go/valyu/citation"
+	"github.com/Veri5ied/valyu-go/valyu/option"
+	"github.com/Veri5ied/valyu-go/valyu/search"
+)
+
+const (
+	// DefaultContextChars bounds the SystemInstructions a Conversation
+	// builds when MaxContextChars is zero.
+	DefaultContextChars = 8000
+	// DefaultSourcesPerTurn is how many sources a Turn keeps when
+	// MaxSourcesPerTurn is zero.
+	DefaultSourcesPerTurn = 5
+
+	snippetChars = 300
+)
+
+// Turn is one question and its answer.
+type Turn struct {
+	Query   string    `json:"query"`
+	Answer  string    `json:"answer"`
+	Sources []Source  `json:"sources,omitempty"`
+	TxID    string    `json:"tx_id,omitempty"`
+	Time    time.Time `json:"time"`
+}
+
+// Source is the part of a search result kept in a conversation. Index is
+// the result's 1-based position in the turn's search results, which is what
+// [n] markers in the answer refer to.
+type Source struct {
+	Index   int    `json:"index"`
+	Title   string `json:"title"`
+	URL     string `json:"url"`
+	Snippet string `json:"snippet,omitempty"`
+}
+
+// Conversation carries earlier turns into follow-up questions. Each call to
+// Ask sends the recent history and its sources as SystemInstructions, newest
+// turns first until MaxContextChars is reached, and records the new turn.
+// Lengths are counted in characters (runes), not bytes.
+//
+// A Conversation is plain data and can be saved with encoding/json. It is
+// not safe for concurrent use.
+type Conversation struct {
+	// Instructions is prepended to the history on every call.
+	Instructions      string `json:"instructions,omitempty"`
+	MaxContextChars   int    `json:"max_context_chars,omitempty"`
+	MaxSourcesPerTurn int    `json:"max_sources_per_turn,omitempty"`
+	Turns             []Turn `json:"turns"`
+}
+
+// Ask answers query in the context of the conversation and appends the turn.
+// SystemInstructions in opts are added after the conversation's own.
+func (c *Conversation) Ask(ctx context.Context, s *Service, query string, opts *Options, reqOpts ...option.RequestOption) (*Response, error) {
+	var o Options
+	if opts != nil {
+		o = *opts
+	}
+	o.SystemInstructions = c.SystemInstructions(o.SystemInstructions)
+
+	resp, err := s.Answer(ctx, query, &o, reqOpts...)
+	if err != nil {
+		return nil, err
+	}
+	c.Add(query, resp)
+	return resp, nil
+}
+
+// Add records a turn from a response obtained elsewhere, e.g. by streaming.
+func (c *Conversation) Add(query string, resp *Response) {
+	text := contentsText(resp.Contents)
+	max := c.MaxSourcesPerTurn
+	if max <= 0 {
+		max = DefaultSourcesPerTurn
+	}
+
+	var sources []Source
+	for _, i := range citedResults(text, resp.SearchResults, max) {
+		r := resp.SearchResults[i]
+		sources = append(sources, Source{
+			Index:   i + 1,
+			Title:   r.Title,
+			URL:     r.URL,
+			Snippet: snippet(r),
+		})
+	}
+
+	c.Turns = append(c.Turns, Turn{
+		Query:   query,
+		Answer:  text,
+		Sources: sources,
+		TxID:    resp.TxID,
+		Time:    time.Now().UTC(),
+	})
+}
+
+// Reset drops all turns.
+func (c *Conversation) Reset() {
+	c.Turns = nil
+}
+
+// SystemInstructions builds the instructions for the next call: the
+// conversation's Instructions, extra, and as much recent history as fits in
+// MaxContextChars.
+func (c *Conversation) SystemInstructions(extra string) string {
+	budget := c.MaxContextChars
+	if budget <= 0 {
+		budget = DefaultContextChars
+	}
+
+	var head []string
+	for _, s := range []string{c.Instructions, extra} {
+		if s = strings.TrimSpace(s); s != "" {
+			head = append(head, s)
+		}
+	}
+	base := strings.Join(head, "\n\n")
+	if len(c.Turns) == 0 {
+		return truncate(base, budget)
+	}
+
+	const intro = "Earlier in this conversation (oldest first):"
+	remaining := budget - utf8.RuneCountInString(base) - len(intro) - 4
+	var blocks []string
+	for i := len(c.Turns) - 1; i >= 0 && remaining > 0; i-- {
+		block := formatTurn(c.Turns[i])
+		if utf8.RuneCountInString(block)+2 > remaining {
+			if len(blocks) > 0 {
+				break
+			}
+			// Always keep part of the latest turn.
+			block = truncate(block, remaining-2)
+		}
+		blocks = append(blocks, block)
+		remaining -= utf8.RuneCountInString(block) + 2
+	}
+	if len(blocks) == 0 {
+		return truncate(base, budget)
+	}
+
+	for i, j := 0, len(blocks)-1; i < j; i, j = i+1, j-1 {
+		blocks[i], blocks[j] = blocks[j], blocks[i]
+	}
+	parts := append(head, intro+"\n\n"+strings.Join(blocks, "\n\n"))
+	return strings.Join(parts, "\n\n")
+}
+
+func formatTurn(t Turn) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "User: %s\nAssistant: %s", t.Query, t.Answer)
+	if len(t.Sources) > 0 {
+		b.WriteString("\nSources:")
+		for _, s := range t.Sources {
+			fmt.Fprintf(&b, "\n[%d] %s <%s>", s.Index, s.Title, s.URL)
+			if s.Snippet != "" {
+				b.WriteString(": " + s.Snippet)
+			}
+		}
+	}
+	return b.String()
+}
+
+// citedResults returns the indices of the results the answer cites, in order
+// of first use, or the first max results if it cites none.
+func citedResults(text string, results []search.Result, max int) []int {
+	cited := citation.Parse(text, results).Cited()
+	if len(cited) == 0 {
+		for i := range results {
+			cited = append(cited, i)
+		}
+	}
+	if len(cited) > max {
+		cited = cited[:max]
+	}
+	return cited
+}
+
+func contentsText(contents interface{}) string {
+	switch v := contents.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	}
+	b, err := json.Marshal(contents)
+	if err != nil {
+		return fmt.Sprint(contents)
+	}
+	return string(b)
+}
+
+func snippet(r search.Result) string {
+	text := r.Description
+	if text == "" {
+		text, _ = r.Content.(string)
+	}
+	return truncate(strings.Join(strings.Fields(text), " "), snippetChars)
+}
+
+// truncate shortens s to at most n runes, ending it with "..." if cut.
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	if n <= 3 {
+		return ""
+	}
+	cut, runes := 0, 0
+	for cut = range s {
+		if runes == n-3 {
+			break
+		}
+		runes++
+	}
+	return s[:cut] + "..."
+}
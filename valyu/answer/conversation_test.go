@@ -0,0 +1,161 @@
+package answer
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/Veri5ied/valyu-go/valyu/search"
+)
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		s    string
+		n    int
+		want string
+	}{
+		{"short", 10, "short"},
+		{"exactly", 7, "exactly"},
+		{"too long", 7, "too ..."},
+		{"héllo wörld", 8, "héllo..."},
+		{"日本語のテキスト", 6, "日本語..."},
+		{"abc", 3, "abc"},
+		{"abcd", 3, ""},
+	}
+	for _, tt := range tests {
+		if got := truncate(tt.s, tt.n); got != tt.want {
+			t.Errorf("truncate(%q, %d) = %q, want %q", tt.s, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestSystemInstructions(t *testing.T) {
+	turn := func(q, a string) Turn { return Turn{Query: q, Answer: a} }
+
+	tests := []struct {
+		name       string
+		conv       Conversation
+		extra      string
+		want       []string
+		wantAbsent []string
+	}{
+		{
+			name:  "no turns",
+			conv:  Conversation{Instructions: " Be brief. "},
+			extra: "Cite sources.",
+			want:  []string{"Be brief.\n\nCite sources."},
+		},
+		{
+			name: "newest turns first until the budget",
+			conv: Conversation{
+				MaxContextChars: 150,
+				Turns:           []Turn{turn("opening", strings.Repeat("a", 60)), turn("second", "two"), turn("third", "three")},
+			},
+			want:       []string{"User: second\nAssistant: two\n\nUser: third\nAssistant: three"},
+			wantAbsent: []string{"opening"},
+		},
+		{
+			name: "latest turn truncated when alone too long",
+			conv: Conversation{
+				MaxContextChars: 100,
+				Turns:           []Turn{turn("q", strings.Repeat("x", 500))},
+			},
+			want: []string{"User: q\nAssistant: xxx", "..."},
+		},
+		{
+			name: "budget counts characters",
+			conv: Conversation{
+				MaxContextChars: 160,
+				Turns:           []Turn{turn("öffnen", strings.Repeat("ü", 30)), turn("日本", strings.Repeat("語", 30))},
+			},
+			want: []string{"User: 日本\nAssistant: " + strings.Repeat("語", 30), "User: öffnen"},
+		},
+		{
+			name: "sources listed",
+			conv: Conversation{Turns: []Turn{{
+				Query:   "q",
+				Answer:  "a [1]",
+				Sources: []Source{{Index: 1, Title: "Paper", URL: "https://a.com", Snippet: "about it"}},
+			}}},
+			want: []string{"Sources:\n[1] Paper <https://a.com>: about it"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.conv.SystemInstructions(tt.extra)
+			budget := tt.conv.MaxContextChars
+			if budget == 0 {
+				budget = DefaultContextChars
+			}
+			if n := utf8.RuneCountInString(got); n > budget {
+				t.Errorf("got %d characters, over the budget of %d:\n%s", n, budget, got)
+			}
+			for _, s := range tt.want {
+				if !strings.Contains(got, s) {
+					t.Errorf("missing %q in:\n%s", s, got)
+				}
+			}
+			for _, s := range tt.wantAbsent {
+				if strings.Contains(got, s) {
+					t.Errorf("unexpected %q in:\n%s", s, got)
+				}
+			}
+		})
+	}
+}
+
+func TestConversationAdd(t *testing.T) {
+	var c Conversation
+	c.MaxSourcesPerTurn = 2
+	c.Add("q", &Response{
+		TxID:     "tx-1",
+		Contents: "Answer [3] and [1].",
+		SearchResults: []search.Result{
+			{Title: "One", URL: "https://a.com/1", Description: "first"},
+			{Title: "Two", URL: "https://a.com/2"},
+			{Title: "Three", URL: "https://a.com/3", Content: "  third\n result "},
+		},
+	})
+
+	if len(c.Turns) != 1 {
+		t.Fatalf("%d turns, want 1", len(c.Turns))
+	}
+	got := c.Turns[0]
+	want := []Source{
+		{Index: 3, Title: "Three", URL: "https://a.com/3", Snippet: "third result"},
+		{Index: 1, Title: "One", URL: "https://a.com/1", Snippet: "first"},
+	}
+	if got.Query != "q" || got.Answer != "Answer [3] and [1]." || got.TxID != "tx-1" || got.Time.IsZero() {
+		t.Errorf("turn = %+v", got)
+	}
+	if !reflect.DeepEqual(got.Sources, want) {
+		t.Errorf("Sources = %+v, want %+v", got.Sources, want)
+	}
+}
+
+func TestConversationJSON(t *testing.T) {
+	c := Conversation{Instructions: "Be brief.", MaxContextChars: 500, MaxSourcesPerTurn: 3}
+	c.Add("What is attention?", &Response{
+		TxID:          "tx-1",
+		Contents:      "A mechanism [1].",
+		SearchResults: []search.Result{{Title: "Attention", URL: "https://arxiv.org/abs/1706.03762", Description: "Transformers"}},
+	})
+	c.Add("Who wrote it?", &Response{Contents: map[string]string{"authors": "Vaswani et al."}})
+
+	b, err := json.Marshal(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got Conversation
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, c) {
+		t.Errorf("round trip =\n%+v\nwant\n%+v", got, c)
+	}
+	if a, b := got.SystemInstructions(""), c.SystemInstructions(""); a != b {
+		t.Errorf("SystemInstructions after round trip =\n%s\nwant\n%s", a, b)
+	}
+}
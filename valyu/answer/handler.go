@@ -0,0 +1,136 @@
+package answer
+
+import (
+	"context"
+	"io"
+	"net/http"
+
+	"github.com/Veri5ied/valyu-go/valyu/option"
+	"github.com/Veri5ied/valyu-go/valyu/search"
+)
+
+// StreamHandler receives the parts of a streamed answer as they arrive.
+// Returning an error from OnSearchResults, OnContent or OnMetadata stops the
+// stream; StreamWith then returns that error without calling OnError.
+type StreamHandler interface {
+	OnSearchResults(results []search.Result) error
+	OnContent(delta string) error
+	OnMetadata(chunk StreamChunk) error
+	// OnError is called once if the stream fails or the API reports an
+	// unsuccessful answer.
+	OnError(err error)
+	// OnDone is called once with the aggregated answer on success.
+	OnDone(resp *Response)
+}
+
+// HandlerFuncs adapts plain functions to a StreamHandler. Nil fields are
+// skipped.
+type HandlerFuncs struct {
+	SearchResults func(results []search.Result) error
+	Content       func(delta string) error
+	Metadata      func(chunk StreamChunk) error
+	Error         func(err error)
+	Done          func(resp *Response)
+}
+
+func (h HandlerFuncs) OnSearchResults(results []search.Result) error {
+	if h.SearchResults == nil {
+		return nil
+	}
+	return h.SearchResults(results)
+}
+
+func (h HandlerFuncs) OnContent(delta string) error {
+	if h.Content == nil {
+		return nil
+	}
+	return h.Content(delta)
+}
+
+func (h HandlerFuncs) OnMetadata(chunk StreamChunk) error {
+	if h.Metadata == nil {
+		return nil
+	}
+	return h.Metadata(chunk)
+}
+
+func (h HandlerFuncs) OnError(err error) {
+	if h.Error != nil {
+		h.Error(err)
+	}
+}
+
+func (h HandlerFuncs) OnDone(resp *Response) {
+	if h.Done != nil {
+		h.Done(resp)
+	}
+}
+
+// StreamWith streams an answer into h and returns the aggregated Response,
+// the same one Answer would return.
+func (s *Service) StreamWith(ctx context.Context, query string, opts *Options, h StreamHandler, reqOpts ...option.RequestOption) (*Response, error) {
+	st, err := s.OpenStream(ctx, query, opts, reqOpts...)
+	if err != nil {
+		h.OnError(err)
+		return nil, err
+	}
+	defer st.Close()
+
+	var agg aggregator
+	for st.Next() {
+		chunk := st.Chunk()
+		agg.add(chunk)
+
+		var err error
+		switch chunk.Type {
+		case ChunkSearchResults:
+			err = h.OnSearchResults(chunk.SearchResults)
+		case ChunkContent:
+			if chunk.Content != "" {
+				err = h.OnContent(chunk.Content)
+			}
+		case ChunkMetadata:
+			err = h.OnMetadata(chunk)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := st.Err(); err != nil {
+		h.OnError(err)
+		return nil, err
+	}
+
+	resp := agg.response()
+	if !resp.Success && s.client.CheckSuccess {
+		err := responseError(resp)
+		h.OnError(err)
+		return nil, err
+	}
+	h.OnDone(resp)
+	return resp, nil
+}
+
+// StreamTo writes the answer text to w as it arrives, flushing after every
+// delta if w is an http.Flusher or has a Flush() error method such as
+// *bufio.Writer. It returns the aggregated Response.
+func (s *Service) StreamTo(ctx context.Context, w io.Writer, query string, opts *Options, reqOpts ...option.RequestOption) (*Response, error) {
+	return s.StreamWith(ctx, query, opts, HandlerFuncs{
+		Content: func(delta string) error {
+			if _, err := io.WriteString(w, delta); err != nil {
+				return err
+			}
+			return flush(w)
+		},
+	}, reqOpts...)
+}
+
+func flush(w io.Writer) error {
+	switch f := w.(type) {
+	case http.Flusher:
+		f.Flush()
+	case interface{ Flush() error }:
+		return f.Flush()
+	}
+	return nil
+}
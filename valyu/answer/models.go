@@ -6,6 +6,8 @@ import (
 )
 
 type Options struct {
+	// StructuredOutput is a JSON Schema for the answer, e.g. from
+	// schema.For. answer.AnswerAs sets it from a Go type.
 	StructuredOutput   interface{}        `json:"structured_output,omitempty"`
 	SystemInstructions string             `json:"system_instructions,omitempty"`
 	SearchType         common.SearchType  `json:"search_type,omitempty"`
@@ -16,6 +18,17 @@ type Options struct {
 	StartDate          string             `json:"start_date,omitempty"`
 	EndDate            string             `json:"end_date,omitempty"`
 	FastMode           bool               `json:"fast_mode,omitempty"`
+
+	// MaxReconnects bounds how often an interrupted stream is resumed with
+	// Last-Event-ID when the server sends event IDs. Zero means
+	// DefaultMaxReconnects; a negative value disables resuming.
+	MaxReconnects int `json:"-"`
+}
+
+// Request is the body sent to /answer. Middleware sees it as Request.Body.
+type Request struct {
+	Query string `json:"query"`
+	Options
 }
 
 type SearchMetadata struct {
@@ -49,8 +62,20 @@ type Response struct {
 	Cost           Cost            `json:"cost,omitempty"`
 }
 
+// ChunkType says what a StreamChunk carries. Its underlying type is string,
+// so comparisons with string literals still compile.
+type ChunkType string
+
+const (
+	ChunkSearchResults ChunkType = "search_results"
+	ChunkContent       ChunkType = "content"
+	ChunkMetadata      ChunkType = "metadata"
+	ChunkError         ChunkType = "error"
+	ChunkDone          ChunkType = "done"
+)
+
 type StreamChunk struct {
-	Type           string          `json:"type"`
+	Type           ChunkType       `json:"type"`
 	SearchResults  []search.Result `json:"search_results,omitempty"`
 	Content        string          `json:"content,omitempty"`
 	FinishReason   string          `json:"finish_reason,omitempty"`
@@ -61,4 +86,13 @@ type StreamChunk struct {
 	AIUsage        *AIUsage        `json:"ai_usage,omitempty"`
 	Cost           *Cost           `json:"cost,omitempty"`
 	Error          string          `json:"error,omitempty"`
+
+	// Event and EventID are the SSE event name ("message" unless the
+	// server names it) and the last event ID, if the server sends IDs.
+	Event   string `json:"-"`
+	EventID string `json:"-"`
+
+	// Err is set on the final "error" chunk sent by Service.Stream when the
+	// stream breaks, e.g. ErrStreamIncomplete or a network error.
+	Err error `json:"-"`
 }
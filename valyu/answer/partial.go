@@ -0,0 +1,116 @@
+package answer
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/Veri5ied/valyu-go/valyu/internal/partialjson"
+	"github.com/Veri5ied/valyu-go/valyu/option"
+	"github.com/Veri5ied/valyu-go/valyu/schema"
+)
+
+// StructuredStream reads a structured answer while it streams and keeps a
+// best-effort decoding of the JSON received so far:
+//
+//	st, err := answer.StreamAs[Summary](ctx, client.Answer, query, nil)
+//	if err != nil { ... }
+//	defer st.Close()
+//	for st.Next() {
+//		if st.Chunk().Type == answer.ChunkContent {
+//			render(st.Partial())
+//		}
+//	}
+//	resp, err := st.Response()
+//
+// Partial values only grow: strings and arrays are cut at what has arrived,
+// and fields whose value has not started yet are left at their zero value.
+type StructuredStream[T any] struct {
+	*ChunkStream
+	schema       *schema.Schema
+	checkSuccess bool
+	agg          aggregator
+	text         strings.Builder
+	partial      T
+}
+
+// StreamAs streams an answer shaped like T, as AnswerAs does for a complete
+// answer.
+func StreamAs[T any](ctx context.Context, s *Service, query string, opts *Options, reqOpts ...option.RequestOption) (*StructuredStream[T], error) {
+	sch, err := schema.For[T]()
+	if err != nil {
+		return nil, err
+	}
+
+	var o Options
+	if opts != nil {
+		o = *opts
+	}
+	o.StructuredOutput = sch
+
+	st, err := s.OpenStream(ctx, query, &o, reqOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return &StructuredStream[T]{ChunkStream: st, schema: sch, checkSuccess: s.client.CheckSuccess}, nil
+}
+
+// Next advances to the next chunk. After a content chunk Partial reflects
+// the new text.
+func (ss *StructuredStream[T]) Next() bool {
+	if !ss.ChunkStream.Next() {
+		return false
+	}
+	chunk := ss.Chunk()
+	ss.agg.add(chunk)
+	if chunk.Type == ChunkContent && chunk.Content != "" {
+		ss.text.WriteString(chunk.Content)
+		ss.update()
+	}
+	return true
+}
+
+func (ss *StructuredStream[T]) update() {
+	doc, ok := partialjson.Complete(unfence(ss.text.String()))
+	if !ok {
+		return
+	}
+	var v T
+	if err := json.Unmarshal([]byte(doc), &v); err != nil {
+		return
+	}
+	ss.partial = v
+}
+
+// Partial returns the latest snapshot of the answer decoded into T.
+func (ss *StructuredStream[T]) Partial() T {
+	return ss.partial
+}
+
+// Response returns the complete answer once Next has returned false. It
+// validates and decodes the contents the same way AnswerAs does.
+func (ss *StructuredStream[T]) Response() (*TypedResponse[T], error) {
+	if err := ss.Err(); err != nil {
+		return nil, err
+	}
+	resp := ss.agg.response()
+	if !resp.Success && ss.checkSuccess {
+		return nil, responseError(resp)
+	}
+	return decodeTyped[T](ss.schema, resp)
+}
+
+// unfence strips a Markdown code fence around JSON, including one that is
+// still open or partly closed.
+func unfence(s string) string {
+	t := strings.TrimLeft(s, " \t\r\n")
+	if !strings.HasPrefix(t, "```") {
+		return s
+	}
+	nl := strings.IndexByte(t, '\n')
+	if nl < 0 {
+		return ""
+	}
+	t = strings.TrimRight(t[nl+1:], " \t\r\n")
+	return strings.TrimRight(t, "`")
+}
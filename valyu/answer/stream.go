@@ -0,0 +1,246 @@
+package answer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/Veri5ied/valyu-go/valyu/common"
+	"github.com/Veri5ied/valyu-go/valyu/internal/sse"
+)
+
+// DefaultMaxReconnects and DefaultReconnectDelay apply when Options leaves
+// MaxReconnects at zero and the server sends no retry: field.
+const (
+	DefaultMaxReconnects  = 3
+	DefaultReconnectDelay = time.Second
+)
+
+// ErrStreamIncomplete is returned when an answer stream ends before the
+// server sent its final [DONE] event, so the answer may be truncated.
+var ErrStreamIncomplete = errors.New("valyu: answer stream ended before completion")
+
+// ChunkStream reads an answer stream one chunk at a time:
+//
+//	st, err := client.Answer.OpenStream(ctx, query, nil)
+//	if err != nil { ... }
+//	defer st.Close()
+//	for st.Next() {
+//		chunk := st.Chunk()
+//		...
+//	}
+//	if err := st.Err(); err != nil { ... }
+//
+// Cancelling the context passed to OpenStream closes the underlying
+// connection and unblocks Next.
+//
+// If the server tags events with IDs and the connection drops, the stream
+// reconnects with a Last-Event-ID header, waits the server's retry: interval
+// in between, and skips events that were already delivered.
+type ChunkStream struct {
+	ctx context.Context
+	dec *sse.Decoder
+
+	mu       sync.Mutex
+	body     io.ReadCloser
+	stop     func() bool
+	closed   bool
+	closeErr error
+
+	reconnect     reconnectFunc
+	maxReconnects int
+	reconnects    int
+	replaying     bool
+	skip          int
+
+	// delivered counts the events read under each ID: the one that set it
+	// and the ID-less ones after it.
+	delivered map[string]int
+
+	chunk StreamChunk
+	err   error
+	done  bool
+}
+
+type reconnectFunc func(ctx context.Context, lastEventID string) (io.ReadCloser, error)
+
+func newChunkStream(ctx context.Context, body io.ReadCloser, reconnect reconnectFunc, maxReconnects int) *ChunkStream {
+	st := &ChunkStream{
+		ctx:           ctx,
+		reconnect:     reconnect,
+		maxReconnects: maxReconnects,
+		delivered:     make(map[string]int),
+	}
+	st.setBody(body)
+	return st
+}
+
+// setBody switches to a new connection, keeping the last event ID and retry
+// interval. It reports false if the stream was closed meanwhile.
+func (st *ChunkStream) setBody(body io.ReadCloser) bool {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if st.closed {
+		body.Close()
+		return false
+	}
+
+	dec := sse.NewDecoder(body)
+	if st.dec != nil {
+		dec.LastEventID = st.dec.LastEventID
+		dec.Retry = st.dec.Retry
+	}
+	st.dec = dec
+	st.body = body
+	st.stop = context.AfterFunc(st.ctx, func() {
+		body.Close()
+	})
+	return true
+}
+
+// Next advances to the next chunk. It returns false when the stream is
+// finished, failed or was closed; Err then tells which.
+func (st *ChunkStream) Next() bool {
+	if st.done || st.err != nil {
+		return false
+	}
+
+	for {
+		ev, err := st.dec.Next()
+		if err != nil {
+			if st.resume() {
+				continue
+			}
+			st.fail(err)
+			return false
+		}
+
+		if st.replaying {
+			// After a reconnect, skip an event whose own ID was already
+			// read, and as many of the ID-less events after it as were
+			// read before.
+			if ev.HasID {
+				st.skip = st.delivered[ev.ID]
+			}
+			if st.skip > 0 {
+				st.skip--
+				continue
+			}
+			st.replaying = false
+		}
+		if ev.HasID {
+			st.delivered[ev.ID] = 1
+		} else if ev.ID != "" {
+			st.delivered[ev.ID]++
+		}
+
+		if ev.Data == "[DONE]" {
+			st.chunk = StreamChunk{Type: ChunkDone, Event: ev.Type, EventID: ev.ID}
+			st.done = true
+			st.Close()
+			return true
+		}
+
+		chunk, ok := ParseChunk(ev.Data)
+		if !ok && ev.Type == "error" {
+			chunk, ok = StreamChunk{Type: ChunkError, Error: ev.Data}, true
+		}
+		if !ok {
+			continue
+		}
+		chunk.Event = ev.Type
+		chunk.EventID = ev.ID
+		st.chunk = chunk
+		return true
+	}
+}
+
+// resume reconnects after the connection broke. It gives up if the server
+// never sent an event ID, the context is done, the reconnect budget is spent
+// or the server rejects the request with a permanent error.
+func (st *ChunkStream) resume() bool {
+	lastEventID := st.dec.LastEventID
+	if st.reconnect == nil || lastEventID == "" || st.ctx.Err() != nil {
+		return false
+	}
+
+	for st.reconnects < st.maxReconnects {
+		st.reconnects++
+
+		st.mu.Lock()
+		closed := st.closed
+		st.stop()
+		st.body.Close()
+		st.mu.Unlock()
+		if closed {
+			return false
+		}
+
+		delay := st.dec.Retry
+		if delay <= 0 {
+			delay = DefaultReconnectDelay
+		}
+		t := time.NewTimer(delay)
+		select {
+		case <-st.ctx.Done():
+			t.Stop()
+			return false
+		case <-t.C:
+		}
+
+		body, err := st.reconnect(st.ctx, lastEventID)
+		if err != nil {
+			if apiErr, ok := common.AsAPIError(err); ok && !apiErr.Temporary() {
+				return false
+			}
+			continue
+		}
+		if !st.setBody(body) {
+			return false
+		}
+		st.replaying = true
+		st.skip = 0
+		return true
+	}
+	return false
+}
+
+func (st *ChunkStream) fail(err error) {
+	switch {
+	case st.ctx.Err() != nil:
+		st.err = st.ctx.Err()
+	case errors.Is(err, io.EOF):
+		st.err = ErrStreamIncomplete
+	default:
+		st.err = fmt.Errorf("read stream: %w", err)
+	}
+	st.Close()
+}
+
+// Chunk returns the chunk read by the last successful call to Next.
+func (st *ChunkStream) Chunk() StreamChunk {
+	return st.chunk
+}
+
+// Err returns the error that stopped the stream, or nil if it completed.
+// A stream that ends without the server's [DONE] event reports
+// ErrStreamIncomplete.
+func (st *ChunkStream) Err() error {
+	return st.err
+}
+
+// Close releases the connection. It is safe to call more than once and
+// concurrently with Next.
+func (st *ChunkStream) Close() error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if !st.closed {
+		st.closed = true
+		st.stop()
+		st.closeErr = st.body.Close()
+	}
+	return st.closeErr
+}
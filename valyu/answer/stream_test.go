@@ -0,0 +1,95 @@
+package answer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// content returns an SSE event carrying a content delta, with an id: field
+// if id is not empty.
+func content(id, text string) string {
+	var b strings.Builder
+	if id != "" {
+		fmt.Fprintf(&b, "id: %s\n", id)
+	}
+	fmt.Fprintf(&b, "data: {\"choices\":[{\"delta\":{\"content\":%q}}]}\n\n", text)
+	return b.String()
+}
+
+const done = "data: [DONE]\n\n"
+
+func TestChunkStreamResume(t *testing.T) {
+	tests := []struct {
+		name       string
+		first      string
+		resumed    []string
+		want       []string
+		wantResume []string
+	}{
+		{
+			name:       "resumed stream continues without ids",
+			first:      "retry: 1\n\n" + content("1", "a") + content("", "b"),
+			resumed:    []string{content("", "c") + content("", "d") + done},
+			want:       []string{"a", "b", "c", "d"},
+			wantResume: []string{"1"},
+		},
+		{
+			name:       "replayed events are skipped with their id-less followers",
+			first:      "retry: 1\n\n" + content("1", "a") + content("", "b") + content("2", "c"),
+			resumed:    []string{content("1", "a") + content("", "b") + content("2", "c") + content("", "d") + content("3", "e") + done},
+			want:       []string{"a", "b", "c", "d", "e"},
+			wantResume: []string{"2"},
+		},
+		{
+			name:  "new id after replay",
+			first: "retry: 1\n\n" + content("1", "a"),
+			resumed: []string{
+				content("1", "a") + content("2", "b"),
+				content("3", "c") + done,
+			},
+			want:       []string{"a", "b", "c"},
+			wantResume: []string{"1", "2"},
+		},
+		{
+			name:       "cleared id is not resumed",
+			first:      "retry: 1\n\n" + content("1", "a") + "id\n" + content("", "b"),
+			want:       []string{"a", "b"},
+			wantResume: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var resumedFrom []string
+			reconnect := func(ctx context.Context, lastEventID string) (io.ReadCloser, error) {
+				n := len(resumedFrom)
+				resumedFrom = append(resumedFrom, lastEventID)
+				if n >= len(tt.resumed) {
+					return nil, io.ErrUnexpectedEOF
+				}
+				return io.NopCloser(strings.NewReader(tt.resumed[n])), nil
+			}
+			st := newChunkStream(context.Background(), io.NopCloser(strings.NewReader(tt.first)), reconnect, 3)
+			defer st.Close()
+
+			var got []string
+			for st.Next() {
+				if c := st.Chunk(); c.Type == ChunkContent {
+					got = append(got, c.Content)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("content = %q, want %q", got, tt.want)
+			}
+			if !reflect.DeepEqual(resumedFrom, tt.wantResume) {
+				t.Errorf("resumed from %q, want %q", resumedFrom, tt.wantResume)
+			}
+			if len(tt.resumed) > 0 && st.Err() != nil {
+				t.Errorf("Err() = %v, want nil", st.Err())
+			}
+		})
+	}
+}
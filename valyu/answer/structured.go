@@ -0,0 +1,66 @@
+package answer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Veri5ied/valyu-go/valyu/option"
+	"github.com/Veri5ied/valyu-go/valyu/schema"
+)
+
+// TypedResponse is a Response whose contents have been decoded into T.
+type TypedResponse[T any] struct {
+	*Response
+	Data T
+}
+
+// AnswerAs asks for an answer shaped like T. The JSON Schema for T is derived
+// from its struct tags and sent as StructuredOutput, replacing any schema set
+// in opts. If the answer does not match the schema, the error is a
+// *schema.ValidationError and the raw Response is still returned.
+func AnswerAs[T any](ctx context.Context, s *Service, query string, opts *Options, reqOpts ...option.RequestOption) (*TypedResponse[T], error) {
+	sch, err := schema.For[T]()
+	if err != nil {
+		return nil, err
+	}
+
+	var o Options
+	if opts != nil {
+		o = *opts
+	}
+	o.StructuredOutput = sch
+
+	resp, err := s.Answer(ctx, query, &o, reqOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeTyped[T](sch, resp)
+}
+
+func decodeTyped[T any](sch *schema.Schema, resp *Response) (*TypedResponse[T], error) {
+	out := &TypedResponse[T]{Response: resp}
+	data, err := structuredContents(resp.Contents)
+	if err != nil {
+		return out, err
+	}
+	if err := schema.ValidateJSON(sch, data); err != nil {
+		return out, err
+	}
+	if err := json.Unmarshal(data, &out.Data); err != nil {
+		return out, fmt.Errorf("answer: decoding structured output: %w", err)
+	}
+	return out, nil
+}
+
+// structuredContents returns the JSON of a structured answer. Streamed
+// answers carry it as text, sometimes inside a Markdown code fence.
+func structuredContents(contents interface{}) ([]byte, error) {
+	s, ok := contents.(string)
+	if !ok {
+		return json.Marshal(contents)
+	}
+	return []byte(strings.TrimSpace(unfence(s))), nil
+}
@@ -0,0 +1,330 @@
+// Package answerhttp relays answer streams to browsers as server-sent
+// events:
+//
+//	http.Handle("/answer", answerhttp.NewHandler(client.Answer))
+//
+// A browser can then read the answer with EventSource("/answer?q=...").
+//
+// EventSource reconnects whenever a response ends, which would start and bill
+// a new answer each time. Every event therefore carries an id, and a request
+// with a Last-Event-ID header, as sent by a reconnecting EventSource, is
+// answered with 204 No Content, which tells the browser to stop. Clients
+// should still call close() when the done event arrives:
+//
+//	const es = new EventSource("/answer?q=" + encodeURIComponent(q));
+//	es.addEventListener("content", e => render(JSON.parse(e.data)));
+//	es.addEventListener("done", () => es.close());
+//	es.addEventListener("error", () => es.close());
+package answerhttp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Veri5ied/valyu-go/valyu/answer"
+	"github.com/Veri5ied/valyu-go/valyu/common"
+	"github.com/Veri5ied/valyu-go/valyu/option"
+)
+
+// DefaultHeartbeat is how often a comment line is sent on an idle stream so
+// proxies do not time the connection out.
+const DefaultHeartbeat = 15 * time.Second
+
+// RequestParser extracts the query and options for a call from an incoming
+// request. An error is reported to the client as 400 Bad Request.
+type RequestParser func(r *http.Request) (query string, opts *answer.Options, err error)
+
+// EventFormat turns a chunk into an SSE event name and data. An empty name
+// sends an unnamed event, which EventSource delivers to onmessage. A nil
+// data slice skips the chunk.
+type EventFormat func(chunk answer.StreamChunk) (event string, data []byte, err error)
+
+// NamedEvents sends each chunk as JSON in an event named after its type:
+// search_results, content, metadata, error or done. It is the default.
+func NamedEvents(chunk answer.StreamChunk) (string, []byte, error) {
+	data, err := json.Marshal(chunk)
+	return string(chunk.Type), data, err
+}
+
+// Messages sends each chunk as JSON in an unnamed event; the chunk's type is
+// in its "type" field.
+func Messages(chunk answer.StreamChunk) (string, []byte, error) {
+	data, err := json.Marshal(chunk)
+	return "", data, err
+}
+
+type config struct {
+	parse       RequestParser
+	requestOpts func(r *http.Request) []option.RequestOption
+	format      EventFormat
+	heartbeat   time.Duration
+	retry       time.Duration
+}
+
+type Option func(*config)
+
+// WithRequestParser replaces the default request parsing. Use it to take
+// options from the request selectively or to apply server-side limits, or
+// pass ParseFullRequest to let clients set every answer option.
+func WithRequestParser(p RequestParser) Option {
+	return func(c *config) {
+		c.parse = p
+	}
+}
+
+// WithRequestOptions sets per-call options derived from the incoming request,
+// such as the caller's own API key via option.WithAPIKey.
+func WithRequestOptions(f func(r *http.Request) []option.RequestOption) Option {
+	return func(c *config) {
+		c.requestOpts = f
+	}
+}
+
+// WithEventFormat sets how chunks are encoded. It defaults to NamedEvents.
+func WithEventFormat(f EventFormat) Option {
+	return func(c *config) {
+		c.format = f
+	}
+}
+
+// WithHeartbeat sets the heartbeat interval. Zero disables heartbeats.
+func WithHeartbeat(d time.Duration) Option {
+	return func(c *config) {
+		c.heartbeat = d
+	}
+}
+
+// WithRetry sends a retry: field asking EventSource clients to wait d
+// before reconnecting.
+func WithRetry(d time.Duration) Option {
+	return func(c *config) {
+		c.retry = d
+	}
+}
+
+type handler struct {
+	svc *answer.Service
+	cfg config
+}
+
+// NewHandler returns an http.Handler that streams an answer for each request.
+// Requests are parsed with ParseRequest unless WithRequestParser is given.
+// The answer is cancelled when the client goes away.
+func NewHandler(svc *answer.Service, opts ...Option) http.Handler {
+	cfg := config{
+		parse:     ParseRequest,
+		format:    NamedEvents,
+		heartbeat: DefaultHeartbeat,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return &handler{svc: svc, cfg: cfg}
+}
+
+// ParseRequest is the default RequestParser. It reads the query from the "q"
+// or "query" parameter of a GET request, or from a POST body holding a JSON
+// object with "query" and, optionally, the options that only narrow the
+// search: search_type, included_sources, excluded_sources, start_date,
+// end_date and country_code. Any other field, such as data_max_price or
+// system_instructions, is rejected, since the body comes from an untrusted
+// client and the call is billed to the server's key.
+func ParseRequest(r *http.Request) (string, *answer.Options, error) {
+	if r.Method != http.MethodPost {
+		return parseQuery(r)
+	}
+	var body struct {
+		Query           string             `json:"query"`
+		SearchType      common.SearchType  `json:"search_type"`
+		IncludedSources []string           `json:"included_sources"`
+		ExcludedSources []string           `json:"excluded_sources"`
+		StartDate       string             `json:"start_date"`
+		EndDate         string             `json:"end_date"`
+		CountryCode     common.CountryCode `json:"country_code"`
+	}
+	if err := decodeBody(r, &body); err != nil {
+		return "", nil, err
+	}
+	if body.Query == "" {
+		return "", nil, errors.New("missing query")
+	}
+	return body.Query, &answer.Options{
+		SearchType:      body.SearchType,
+		IncludedSources: body.IncludedSources,
+		ExcludedSources: body.ExcludedSources,
+		StartDate:       body.StartDate,
+		EndDate:         body.EndDate,
+		CountryCode:     body.CountryCode,
+	}, nil
+}
+
+// ParseFullRequest is like ParseRequest but accepts every answer.Options
+// field in a POST body, including ones that raise the cost of a call. Only
+// use it behind authentication, with WithRequestParser.
+func ParseFullRequest(r *http.Request) (string, *answer.Options, error) {
+	if r.Method != http.MethodPost {
+		return parseQuery(r)
+	}
+	var body struct {
+		Query string `json:"query"`
+		answer.Options
+	}
+	if err := decodeBody(r, &body); err != nil {
+		return "", nil, err
+	}
+	if body.Query == "" {
+		return "", nil, errors.New("missing query")
+	}
+	return body.Query, &body.Options, nil
+}
+
+func parseQuery(r *http.Request) (string, *answer.Options, error) {
+	if r.Method != http.MethodGet {
+		return "", nil, fmt.Errorf("method %s not allowed", r.Method)
+	}
+	q := r.URL.Query()
+	query := q.Get("q")
+	if query == "" {
+		query = q.Get("query")
+	}
+	if query == "" {
+		return "", nil, errors.New("missing q parameter")
+	}
+	return query, nil, nil
+}
+
+func decodeBody(r *http.Request, v interface{}) error {
+	dec := json.NewDecoder(io.LimitReader(r.Body, 1<<20))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("invalid request body: %w", err)
+	}
+	return nil
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Last-Event-ID") != "" {
+		// A reconnecting EventSource; its answer was already sent.
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	query, opts, err := h.cfg.parse(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	rc := http.NewResponseController(w)
+	header := w.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	ew := &eventWriter{w: w, rc: rc}
+	if h.cfg.retry > 0 {
+		ew.printf("retry: %d\n\n", h.cfg.retry.Milliseconds())
+	}
+	if ew.flush() != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	var reqOpts []option.RequestOption
+	if h.cfg.requestOpts != nil {
+		reqOpts = h.cfg.requestOpts(r)
+	}
+	chunks, err := h.svc.Stream(ctx, query, opts, reqOpts...)
+	if err != nil {
+		h.send(ew, answer.StreamChunk{Type: answer.ChunkError, Error: err.Error(), Err: err})
+		return
+	}
+
+	var tick <-chan time.Time
+	if h.cfg.heartbeat > 0 {
+		t := time.NewTicker(h.cfg.heartbeat)
+		defer t.Stop()
+		tick = t.C
+	}
+
+	for {
+		select {
+		case chunk, ok := <-chunks:
+			if !ok {
+				return
+			}
+			if h.send(ew, chunk) != nil {
+				return
+			}
+		case <-tick:
+			ew.printf(": ping\n\n")
+			if ew.flush() != nil {
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (h *handler) send(ew *eventWriter, chunk answer.StreamChunk) error {
+	event, data, err := h.cfg.format(chunk)
+	if err != nil {
+		chunk = answer.StreamChunk{Type: answer.ChunkError, Error: err.Error()}
+		if event, data, err = NamedEvents(chunk); err != nil {
+			return err
+		}
+	}
+	if data == nil {
+		return nil
+	}
+	ew.id++
+	ew.event(event, data)
+	return ew.flush()
+}
+
+// eventWriter writes SSE fields and remembers the first write error. id is
+// the ID of the last event written.
+type eventWriter struct {
+	w   io.Writer
+	rc  *http.ResponseController
+	id  int
+	err error
+}
+
+func (ew *eventWriter) printf(format string, args ...interface{}) {
+	if ew.err == nil {
+		_, ew.err = fmt.Fprintf(ew.w, format, args...)
+	}
+}
+
+func (ew *eventWriter) event(name string, data []byte) {
+	ew.printf("id: %d\n", ew.id)
+	if name != "" {
+		ew.printf("event: %s\n", strings.NewReplacer("\r", "", "\n", "").Replace(name))
+	}
+	text := strings.NewReplacer("\r\n", "\n", "\r", "\n").Replace(string(data))
+	for _, line := range strings.Split(text, "\n") {
+		ew.printf("data: %s\n", line)
+	}
+	ew.printf("\n")
+}
+
+func (ew *eventWriter) flush() error {
+	if ew.err != nil {
+		return ew.err
+	}
+	if err := ew.rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		ew.err = err
+	}
+	return ew.err
+}
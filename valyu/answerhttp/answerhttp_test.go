@@ -0,0 +1,162 @@
+package answerhttp
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/Veri5ied/valyu-go/valyu/answer"
+	"github.com/Veri5ied/valyu-go/valyu/internal/api"
+	"github.com/Veri5ied/valyu-go/valyu/internal/sse"
+)
+
+const upstreamBody = `data: {"search_results":[{"title":"a","url":"https://a.com"}]}
+
+data: {"choices":[{"delta":{"content":"hello"}}]}
+
+data: {"success":true,"tx_id":"tx-1"}
+
+data: [DONE]
+
+`
+
+// newRelay serves NewHandler in front of a fake /answer endpoint and
+// returns the relay's URL and the number of upstream calls made.
+func newRelay(t *testing.T, opts ...Option) (string, *int32) {
+	t.Helper()
+	var calls int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(w, upstreamBody)
+	}))
+	t.Cleanup(upstream.Close)
+
+	svc := answer.New(api.New(upstream.URL, "test-key", upstream.Client()))
+	relay := httptest.NewServer(NewHandler(svc, append([]Option{WithHeartbeat(0)}, opts...)...))
+	t.Cleanup(relay.Close)
+	return relay.URL, &calls
+}
+
+func readEvents(t *testing.T, body io.Reader) []sse.Event {
+	t.Helper()
+	var events []sse.Event
+	dec := sse.NewDecoder(body)
+	for {
+		ev, err := dec.Next()
+		if errors.Is(err, io.EOF) {
+			return events
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		events = append(events, ev)
+	}
+}
+
+// TestReconnectIsNotRebilled does what EventSource does: read the stream to
+// its end, then reconnect with the last event ID.
+func TestReconnectIsNotRebilled(t *testing.T) {
+	url, calls := newRelay(t)
+
+	resp, err := http.Get(url + "?q=hi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := readEvents(t, resp.Body)
+	resp.Body.Close()
+
+	var types []string
+	for i, ev := range events {
+		types = append(types, ev.Type)
+		if want := strconv.Itoa(i + 1); ev.ID != want {
+			t.Errorf("event %d has id %q, want %q", i, ev.ID, want)
+		}
+	}
+	if want := []string{"search_results", "content", "metadata", "done"}; !reflect.DeepEqual(types, want) {
+		t.Fatalf("event types = %q, want %q", types, want)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, url+"?q=hi", nil)
+	req.Header.Set("Last-Event-ID", events[len(events)-1].ID)
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("reconnect status = %d, want 204", resp.StatusCode)
+	}
+	if n := atomic.LoadInt32(calls); n != 1 {
+		t.Errorf("upstream was called %d times, want 1", n)
+	}
+}
+
+func TestParseRequest(t *testing.T) {
+	tests := []struct {
+		name      string
+		parse     RequestParser
+		method    string
+		target    string
+		body      string
+		wantQuery string
+		wantOpts  *answer.Options
+		wantErr   bool
+	}{
+		{name: "get q", parse: ParseRequest, method: "GET", target: "/?q=hi", wantQuery: "hi"},
+		{name: "get query", parse: ParseRequest, method: "GET", target: "/?query=hi", wantQuery: "hi"},
+		{name: "get missing", parse: ParseRequest, method: "GET", target: "/", wantErr: true},
+		{name: "put", parse: ParseRequest, method: "PUT", target: "/", body: `{"query":"hi"}`, wantErr: true},
+		{
+			name:      "post safe options",
+			parse:     ParseRequest,
+			method:    "POST",
+			body:      `{"query":"hi","search_type":"web","included_sources":["arxiv.org"],"start_date":"2024-01-01"}`,
+			wantQuery: "hi",
+			wantOpts:  &answer.Options{SearchType: "web", IncludedSources: []string{"arxiv.org"}, StartDate: "2024-01-01"},
+		},
+		{name: "post price", parse: ParseRequest, method: "POST", body: `{"query":"hi","data_max_price":1000}`, wantErr: true},
+		{name: "post instructions", parse: ParseRequest, method: "POST", body: `{"query":"hi","system_instructions":"x"}`, wantErr: true},
+		{name: "post missing query", parse: ParseRequest, method: "POST", body: `{"search_type":"web"}`, wantErr: true},
+		{
+			name:      "full post price",
+			parse:     ParseFullRequest,
+			method:    "POST",
+			body:      `{"query":"hi","data_max_price":1000}`,
+			wantQuery: "hi",
+			wantOpts:  &answer.Options{DataMaxPrice: 1000},
+		},
+		{name: "full get", parse: ParseFullRequest, method: "GET", target: "/?q=hi", wantQuery: "hi"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := tt.target
+			if target == "" {
+				target = "/"
+			}
+			r := httptest.NewRequest(tt.method, target, strings.NewReader(tt.body))
+			query, opts, err := tt.parse(r)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("got query %q, want error", query)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if query != tt.wantQuery {
+				t.Errorf("query = %q, want %q", query, tt.wantQuery)
+			}
+			if !reflect.DeepEqual(opts, tt.wantOpts) {
+				t.Errorf("opts = %+v, want %+v", opts, tt.wantOpts)
+			}
+		})
+	}
+}
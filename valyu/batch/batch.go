@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/Veri5ied/valyu-go/valyu/internal/api"
+	"github.com/Veri5ied/valyu-go/valyu/option"
 )
 
 type Service struct {
@@ -15,25 +16,25 @@ func New(client *api.Client) *Service {
 	return &Service{client: client}
 }
 
-func (s *Service) Create(ctx context.Context, opts *CreateOptions) (*CreateResponse, error) {
+func (s *Service) Create(ctx context.Context, opts *CreateOptions, reqOpts ...option.RequestOption) (*CreateResponse, error) {
 	var resp CreateResponse
-	if err := s.client.Post(ctx, "/batch", opts, &resp); err != nil {
+	if err := s.client.Post(ctx, "/batch", opts, &resp, reqOpts...); err != nil {
 		return nil, err
 	}
 	return &resp, nil
 }
 
-func (s *Service) List(ctx context.Context) (*ListResponse, error) {
+func (s *Service) List(ctx context.Context, reqOpts ...option.RequestOption) (*ListResponse, error) {
 	var resp ListResponse
-	if err := s.client.Get(ctx, "/batch", &resp); err != nil {
+	if err := s.client.Get(ctx, "/batch", &resp, reqOpts...); err != nil {
 		return nil, err
 	}
 	return &resp, nil
 }
 
-func (s *Service) Get(ctx context.Context, batchID string) (*StatusResponse, error) {
+func (s *Service) Get(ctx context.Context, batchID string, reqOpts ...option.RequestOption) (*StatusResponse, error) {
 	var resp StatusResponse
-	if err := s.client.Get(ctx, fmt.Sprintf("/batch/%s", batchID), &resp); err != nil {
+	if err := s.client.Get(ctx, fmt.Sprintf("/batch/%s", batchID), &resp, reqOpts...); err != nil {
 		return nil, err
 	}
 	return &resp, nil
@@ -0,0 +1,404 @@
+// Package citation links the text of an answer to the search results it
+// cites. It recognises numeric markers such as [1], [1, 3] and [2-4],
+// Markdown footnotes ([^1] with a "[^1]: url" definition), Markdown links and
+// bare URLs, and splits the text into sentence spans so that every claim can
+// be traced to its sources:
+//
+//	doc := citation.Parse(text, resp.SearchResults)
+//	for _, span := range doc.Uncited() {
+//		log.Printf("unsupported claim: %q", span.Text)
+//	}
+//	fmt.Println(doc.Markdown())
+package citation
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Veri5ied/valyu-go/valyu/search"
+)
+
+// Kind says how a marker refers to its sources.
+type Kind int
+
+const (
+	Numeric  Kind = iota // [1], [1, 2], [1-3]
+	Footnote             // [^1]
+	Link                 // [text](https://...)
+	URL                  // https://...
+)
+
+// Marker is a citation in the text. Start and End are byte offsets; Results
+// holds 0-based indices into the search results and is empty if the marker
+// could not be resolved.
+type Marker struct {
+	Start   int
+	End     int
+	Kind    Kind
+	Label   string
+	Results []int
+}
+
+// Span is a sentence or line of the text with the results it cites.
+type Span struct {
+	Start   int
+	End     int
+	Text    string
+	Markers []Marker
+	Results []int
+}
+
+// Document is a parsed answer.
+type Document struct {
+	Text    string
+	Results []search.Result
+	Markers []Marker
+	Spans   []Span
+
+	// defs are the byte ranges of footnote definition lines, which are
+	// not part of any span and are dropped when rendering.
+	defs [][2]int
+}
+
+var (
+	definitionRe = regexp.MustCompile(`(?m)^[ \t]*\[(\^?[\w-]+)\]:[ \t]*(.*)$`)
+	markerRe     = regexp.MustCompile(`\[(\^[\w-]+)\]|\[(\d+(?:\s*[-–,]\s*\d+)*)\](?:\(([^)\s]+)\))?|\[([^\[\]\n]*)\]\((https?://[^)\s]+)\)|https?://[^\s<>()\[\]"'` + "`" + `]+`)
+	urlRe        = regexp.MustCompile(`https?://[^\s<>()\[\]"'` + "`" + `]+`)
+)
+
+// Parse finds the citations in text and resolves them against results.
+func Parse(text string, results []search.Result) *Document {
+	d := &Document{Text: text, Results: results}
+
+	byURL := map[string]int{}
+	for i, r := range results {
+		if key := normalizeURL(r.URL); key != "" {
+			if _, dup := byURL[key]; !dup {
+				byURL[key] = i
+			}
+		}
+	}
+	resolveURL := func(u string) []int {
+		if i, ok := byURL[normalizeURL(u)]; ok {
+			return []int{i}
+		}
+		return nil
+	}
+
+	// Footnote definitions map a label to a URL, e.g. "[^1]: https://...".
+	defURL := map[string]string{}
+	for _, m := range definitionRe.FindAllStringSubmatchIndex(text, -1) {
+		label := text[m[2]:m[3]]
+		if u := urlRe.FindString(text[m[4]:m[5]]); u != "" {
+			defURL[label] = trimURL(u)
+		}
+		end := m[1]
+		if end < len(text) && text[end] == '\n' {
+			end++
+		}
+		d.defs = append(d.defs, [2]int{m[0], end})
+	}
+
+	for _, m := range markerRe.FindAllStringSubmatchIndex(text, -1) {
+		if d.inDefinition(m[0]) {
+			continue
+		}
+		mk := Marker{Start: m[0], End: m[1]}
+		switch {
+		case m[2] >= 0:
+			mk.Kind = Footnote
+			mk.Label = text[m[2]:m[3]]
+			if u, ok := defURL[mk.Label]; ok {
+				mk.Results = resolveURL(u)
+			} else if n, err := strconv.Atoi(mk.Label[1:]); err == nil {
+				mk.Results = resolveIndex(n, len(results))
+			}
+		case m[4] >= 0:
+			mk.Kind = Numeric
+			mk.Label = text[m[4]:m[5]]
+			if m[6] >= 0 {
+				mk.Kind = Link
+				mk.Results = resolveURL(text[m[6]:m[7]])
+				break
+			}
+			for _, n := range expandNumbers(mk.Label) {
+				if u, ok := defURL[strconv.Itoa(n)]; ok {
+					mk.Results = appendUnique(mk.Results, resolveURL(u)...)
+				} else {
+					mk.Results = appendUnique(mk.Results, resolveIndex(n, len(results))...)
+				}
+			}
+		case m[8] >= 0:
+			mk.Kind = Link
+			mk.Label = text[m[8]:m[9]]
+			mk.Results = resolveURL(text[m[10]:m[11]])
+		default:
+			u := trimURL(text[m[0]:m[1]])
+			mk.Kind = URL
+			mk.End = m[0] + len(u)
+			mk.Label = u
+			mk.Results = resolveURL(u)
+		}
+		d.Markers = append(d.Markers, mk)
+	}
+
+	d.Spans = d.split()
+	return d
+}
+
+// Cited returns the indices of all cited results in order of first use.
+func (d *Document) Cited() []int {
+	var out []int
+	for _, m := range d.Markers {
+		out = appendUnique(out, m.Results...)
+	}
+	return out
+}
+
+// Uncited returns the spans that cite no result.
+func (d *Document) Uncited() []Span {
+	var out []Span
+	for _, s := range d.Spans {
+		if len(s.Results) == 0 {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// Unresolved returns the markers that could not be matched to a result.
+// Bare URLs that are not search results are not included.
+func (d *Document) Unresolved() []Marker {
+	var out []Marker
+	for _, m := range d.Markers {
+		if len(m.Results) == 0 && m.Kind != URL {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+func (d *Document) inDefinition(pos int) bool {
+	for _, r := range d.defs {
+		if pos >= r[0] && pos < r[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// split cuts the text into spans at line breaks and at sentence-ending
+// punctuation followed by whitespace, except after abbreviations. Markers
+// right after the punctuation, as in "claim.[1]", stay with the sentence.
+func (d *Document) split() []Span {
+	text := d.Text
+	var spans []Span
+	mi := 0
+	start := 0
+
+	emit := func(end int) {
+		s, e := start, end
+		for s < e && isSpace(text[s]) {
+			s++
+		}
+		for e > s && isSpace(text[e-1]) {
+			e--
+		}
+		start = end
+		if s == e {
+			return
+		}
+		span := Span{Start: s, End: e, Text: text[s:e]}
+		for _, m := range d.Markers {
+			if m.Start >= s && m.Start < e {
+				span.Markers = append(span.Markers, m)
+				span.Results = appendUnique(span.Results, m.Results...)
+			}
+		}
+		spans = append(spans, span)
+	}
+
+	for i := 0; i < len(text); i++ {
+		if d.inDefinition(i) {
+			emit(i)
+			for i < len(text) && d.inDefinition(i) {
+				i++
+			}
+			start = i
+			i--
+			continue
+		}
+		for mi < len(d.Markers) && d.Markers[mi].End <= i {
+			mi++
+		}
+		if mi < len(d.Markers) && d.Markers[mi].Start <= i {
+			i = d.Markers[mi].End - 1
+			continue
+		}
+
+		switch c := text[i]; {
+		case c == '\n':
+			emit(i + 1)
+		case c == '.' && abbreviation(text, i):
+		case c == '.' || c == '!' || c == '?':
+			end := i + 1
+			for end < len(text) && strings.IndexByte(".!?\"')", text[end]) >= 0 {
+				end++
+			}
+			for k := mi; k < len(d.Markers) && d.Markers[k].Start == skipSpaces(text, end); k++ {
+				if d.Markers[k].Kind == URL {
+					break
+				}
+				end = d.Markers[k].End
+			}
+			if end == len(text) || isSpace(text[end]) {
+				emit(end)
+				i = end - 1
+			}
+		}
+	}
+	emit(len(text))
+	return spans
+}
+
+// inlineAbbreviations never end a sentence; closingAbbreviations may, and
+// do when the next word is capitalised. Both are lower case without the
+// final period.
+var (
+	inlineAbbreviations = map[string]bool{
+		"e.g": true, "i.e": true, "cf": true, "vs": true, "viz": true, "approx": true, "ca": true,
+		"dr": true, "mr": true, "mrs": true, "prof": true, "fig": true, "figs": true,
+		"eq": true, "eqs": true, "ref": true, "refs": true, "vol": true, "pp": true,
+	}
+	closingAbbreviations = map[string]bool{
+		"al": true, "etc": true, "inc": true, "ltd": true, "co": true, "corp": true, "jr": true, "sr": true, "no": true,
+	}
+)
+
+// abbreviation reports whether the period at i ends an abbreviation, such
+// as "e.g.", "Dr." or "et al.", rather than a sentence. A capital initial,
+// as in "J. Smith", never ends one; dotted initials like "U.S." are treated
+// as closing abbreviations.
+func abbreviation(text string, i int) bool {
+	if i+1 < len(text) && !isSpace(text[i+1]) {
+		// "no." or "(etc.)": other punctuation follows, so the period is
+		// handled as a sentence end.
+		return false
+	}
+	j := i
+	for j > 0 && (isLetter(text[j-1]) || text[j-1] == '.') {
+		j--
+	}
+	if i-j == 1 && text[j] >= 'A' && text[j] <= 'Z' {
+		return true
+	}
+	word := strings.ToLower(text[j:i])
+	if word == "" || word[0] == '.' {
+		return false
+	}
+	if inlineAbbreviations[word] {
+		return true
+	}
+	if !closingAbbreviations[word] && !initials(word) {
+		return false
+	}
+	next := i + 1
+	for next < len(text) && isSpace(text[next]) {
+		next++
+	}
+	return next < len(text) && !(text[next] >= 'A' && text[next] <= 'Z')
+}
+
+// initials reports whether word is single letters joined by periods.
+func initials(word string) bool {
+	for _, part := range strings.Split(word, ".") {
+		if len(part) != 1 {
+			return false
+		}
+	}
+	return true
+}
+
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func skipSpaces(s string, i int) int {
+	for i < len(s) && (s[i] == ' ' || s[i] == '\t') {
+		i++
+	}
+	return i
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+func resolveIndex(n, count int) []int {
+	if n >= 1 && n <= count {
+		return []int{n - 1}
+	}
+	return nil
+}
+
+// expandNumbers turns "1, 3-5" into [1 3 4 5].
+func expandNumbers(label string) []int {
+	var out []int
+	for _, part := range strings.Split(label, ",") {
+		part = strings.TrimSpace(part)
+		lo, hi, isRange := strings.Cut(strings.ReplaceAll(part, "–", "-"), "-")
+		a, err := strconv.Atoi(strings.TrimSpace(lo))
+		if err != nil {
+			continue
+		}
+		b := a
+		if isRange {
+			if b, err = strconv.Atoi(strings.TrimSpace(hi)); err != nil || b < a || b-a > 100 {
+				b = a
+			}
+		}
+		for n := a; n <= b; n++ {
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+func appendUnique(s []int, vs ...int) []int {
+	for _, v := range vs {
+		found := false
+		for _, x := range s {
+			if x == v {
+				found = true
+				break
+			}
+		}
+		if !found {
+			s = append(s, v)
+		}
+	}
+	return s
+}
+
+// trimURL drops punctuation that ends the surrounding sentence.
+func trimURL(u string) string {
+	return strings.TrimRight(u, ".,;:!?")
+}
+
+// normalizeURL makes URLs that differ only in scheme, host case, "www.", a
+// trailing slash or a fragment compare equal.
+func normalizeURL(u string) string {
+	u = strings.TrimSpace(u)
+	if i := strings.IndexByte(u, '#'); i >= 0 {
+		u = u[:i]
+	}
+	u = strings.TrimPrefix(strings.TrimPrefix(u, "https://"), "http://")
+	u = strings.TrimRight(u, "/")
+	host, path, _ := strings.Cut(u, "/")
+	host = strings.TrimPrefix(strings.ToLower(host), "www.")
+	if path == "" {
+		return host
+	}
+	return host + "/" + path
+}
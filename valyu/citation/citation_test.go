@@ -0,0 +1,204 @@
+package citation
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Veri5ied/valyu-go/valyu/search"
+)
+
+var testResults = []search.Result{
+	{Title: "Attention Is All You Need", URL: "https://arxiv.org/abs/1706.03762"},
+	{Title: "BERT", URL: "https://www.aclweb.org/anthology/N19-1423/"},
+	{Title: "GPT-3", URL: "http://papers.nips.cc/paper/gpt3"},
+	{Title: "T5 [v2]", URL: "https://jmlr.org/papers/t5"},
+}
+
+func TestParseMarkers(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []Marker
+	}{
+		{"numeric", "Claim [1].", []Marker{{Start: 6, End: 9, Kind: Numeric, Label: "1", Results: []int{0}}}},
+		{"list", "Claim [1, 3].", []Marker{{Start: 6, End: 12, Kind: Numeric, Label: "1, 3", Results: []int{0, 2}}}},
+		{"range", "Claim [2-4].", []Marker{{Start: 6, End: 11, Kind: Numeric, Label: "2-4", Results: []int{1, 2, 3}}}},
+		{"en dash range", "Claim [1–2].", []Marker{{Start: 6, End: 13, Kind: Numeric, Label: "1–2", Results: []int{0, 1}}}},
+		{"duplicates", "Claim [1,1-2].", []Marker{{Start: 6, End: 13, Kind: Numeric, Label: "1,1-2", Results: []int{0, 1}}}},
+		{"out of range", "Claim [9].", []Marker{{Start: 6, End: 9, Kind: Numeric, Label: "9"}}},
+		{"reversed range", "Claim [3-1].", []Marker{{Start: 6, End: 11, Kind: Numeric, Label: "3-1", Results: []int{2}}}},
+		{"footnote by index", "Claim[^2].", []Marker{{Start: 5, End: 9, Kind: Footnote, Label: "^2", Results: []int{1}}}},
+		{
+			"footnote definition",
+			"Claim[^a].\n\n[^a]: https://arxiv.org/abs/1706.03762.\n",
+			[]Marker{{Start: 5, End: 9, Kind: Footnote, Label: "^a", Results: []int{0}}},
+		},
+		{
+			"numeric with definition",
+			"Claim [1].\n[1]: https://jmlr.org/papers/t5\n",
+			[]Marker{{Start: 6, End: 9, Kind: Numeric, Label: "1", Results: []int{3}}},
+		},
+		{
+			"numbered link",
+			"Claim [2](https://aclweb.org/anthology/N19-1423).",
+			[]Marker{{Start: 6, End: 48, Kind: Link, Label: "2", Results: []int{1}}},
+		},
+		{
+			"markdown link",
+			"See [the paper](http://arxiv.org/abs/1706.03762/#sec2).",
+			[]Marker{{Start: 4, End: 54, Kind: Link, Label: "the paper", Results: []int{0}}},
+		},
+		{
+			"bare url",
+			"See https://papers.nips.cc/paper/gpt3.",
+			[]Marker{{Start: 4, End: 37, Kind: URL, Label: "https://papers.nips.cc/paper/gpt3", Results: []int{2}}},
+		},
+		{
+			"unknown url",
+			"See https://example.com",
+			[]Marker{{Start: 4, End: 23, Kind: URL, Label: "https://example.com"}},
+		},
+		{"not a marker", "Array [a] and [].", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Parse(tt.text, testResults).Markers
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Markers =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSpans(t *testing.T) {
+	type span struct {
+		Text    string
+		Results []int
+	}
+	tests := []struct {
+		name string
+		text string
+		want []span
+	}{
+		{"sentences", "First [1]. Second [2]! Third?", []span{{"First [1].", []int{0}}, {"Second [2]!", []int{1}}, {"Third?", nil}}},
+		{"marker after period", "First.[1] Second. [2][3] Third.", []span{{"First.[1]", []int{0}}, {"Second. [2][3]", []int{1, 2}}, {"Third.", nil}}},
+		{"lines", "- one [1]\n- two\n\nthree [2]", []span{{"- one [1]", []int{0}}, {"- two", nil}, {"three [2]", []int{1}}}},
+		{"decimal", "It rose 3.5 percent [1].", []span{{"It rose 3.5 percent [1].", []int{0}}}},
+		{"quote", `He said "no." Then left [1].`, []span{{`He said "no."`, nil}, {"Then left [1].", []int{0}}}},
+		{"url before period", "See https://arxiv.org/abs/1706.03762. Next.", []span{{"See https://arxiv.org/abs/1706.03762.", []int{0}}, {"Next.", nil}}},
+		{"definitions skipped", "Claim[^1].\n\n[^1]: https://jmlr.org/papers/t5\nMore [2].", []span{{"Claim[^1].", []int{3}}, {"More [2].", []int{1}}}},
+		{"e.g.", "Also e.g. this is it [3].", []span{{"Also e.g. this is it [3].", []int{2}}}},
+		{"i.e.", "One model, i.e. BERT, wins [2].", []span{{"One model, i.e. BERT, wins [2].", []int{1}}}},
+		{"title", "Dr. Smith agrees [1]. Prof. Jones does not [2].", []span{{"Dr. Smith agrees [1].", []int{0}}, {"Prof. Jones does not [2].", []int{1}}}},
+		{"et al. mid sentence", "Vaswani et al. proposed it [1].", []span{{"Vaswani et al. proposed it [1].", []int{0}}}},
+		{"et al. ends sentence", "It is by Vaswani et al. They used attention [1].", []span{{"It is by Vaswani et al.", nil}, {"They used attention [1].", []int{0}}}},
+		{"initials", "J. R. R. Tolkien wrote it. The U.S. market grew [2].", []span{{"J. R. R. Tolkien wrote it.", nil}, {"The U.S. market grew [2].", []int{1}}}},
+		{"no. before a number", "See No. 5 for details [1]. No. It is not.", []span{{"See No. 5 for details [1].", []int{0}}, {"No.", nil}, {"It is not.", nil}}},
+		{"etc. ends sentence", "Cats, dogs, etc. Pets are common [1].", []span{{"Cats, dogs, etc.", nil}, {"Pets are common [1].", []int{0}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := Parse(tt.text, testResults)
+			var got []span
+			for _, s := range doc.Spans {
+				if tt.text[s.Start:s.End] != s.Text {
+					t.Errorf("span %q has offsets [%d:%d] = %q", s.Text, s.Start, s.End, tt.text[s.Start:s.End])
+				}
+				got = append(got, span{s.Text, s.Results})
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Spans =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDocumentQueries(t *testing.T) {
+	doc := Parse("Also e.g. this is it [3]. Unsupported claim. Bad ref [7]. See https://example.com. Again [1][3].", testResults)
+
+	var uncited []string
+	for _, s := range doc.Uncited() {
+		uncited = append(uncited, s.Text)
+	}
+	if want := []string{"Unsupported claim.", "Bad ref [7].", "See https://example.com."}; !reflect.DeepEqual(uncited, want) {
+		t.Errorf("Uncited = %q, want %q", uncited, want)
+	}
+	if got, want := doc.Cited(), []int{2, 0}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Cited = %v, want %v", got, want)
+	}
+	if got := doc.Unresolved(); len(got) != 1 || got[0].Label != "7" {
+		t.Errorf("Unresolved = %+v, want only [7]", got)
+	}
+}
+
+func TestMarkdown(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"no citations", "Plain text.\n", "Plain text."},
+		{
+			"numeric and footnotes",
+			"Claim [2, 1]. Other[^a].\n\n[^a]: https://jmlr.org/papers/t5\n",
+			"Claim [^2][^1]. Other[^4].\n\n[^2]: [BERT](https://www.aclweb.org/anthology/N19-1423/)\n[^1]: [Attention Is All You Need](https://arxiv.org/abs/1706.03762)\n[^4]: [T5 \\[v2\\]](https://jmlr.org/papers/t5)",
+		},
+		{
+			"unresolved and links kept",
+			"Bad [9]. [BERT](https://aclweb.org/anthology/N19-1423) and https://example.com.",
+			"Bad [9]. [BERT](https://aclweb.org/anthology/N19-1423) and https://example.com.",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Parse(tt.text, testResults).Markdown(); got != tt.want {
+				t.Errorf("Markdown =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHTML(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"escaped text", "a < b & c", "<p>a &lt; b &amp; c</p>\n"},
+		{"paragraphs and breaks", "one\ntwo\n\nthree", "<p>one<br>\ntwo</p>\n<p>three</p>\n"},
+		{
+			"citation",
+			"Claim [1].",
+			`<p>Claim <sup class="citation"><a href="https://arxiv.org/abs/1706.03762" title="Attention Is All You Need">[1]</a></sup>.</p>` + "\n" +
+				`<ol class="sources">` + "\n" +
+				`<li value="1"><a href="https://arxiv.org/abs/1706.03762">Attention Is All You Need</a></li>` + "\n</ol>\n",
+		},
+		{
+			"links and urls",
+			"[x](https://a.com/?q=1&r=2) https://b.com",
+			`<p><a href="https://a.com/?q=1&amp;r=2">x</a> <a href="https://b.com">https://b.com</a></p>` + "\n",
+		},
+		{"unresolved", "Bad [9].", "<p>Bad [9].</p>\n"},
+		{"definitions dropped", "Claim.\n\n[^1]: https://example.com\n", "<p>Claim.</p>\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Parse(tt.text, testResults).HTML(); got != tt.want {
+				t.Errorf("HTML =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSafeURL(t *testing.T) {
+	for in, want := range map[string]string{
+		"https://a.com/x?y=1&z=2": "https://a.com/x?y=1&amp;z=2",
+		"HTTP://A.COM":            "HTTP://A.COM",
+		"javascript:alert(1)":     "#",
+		"//evil.com":              "#",
+	} {
+		if got := safeURL(in); got != want {
+			t.Errorf("safeURL(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package citation
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+// Markdown returns the text with numeric markers and footnotes rewritten as
+// Markdown footnote references, numbered by result position, followed by a
+// definition for each cited result. Unresolved markers, links and URLs are
+// left as they are.
+func (d *Document) Markdown() string {
+	var b strings.Builder
+	var used []int
+	d.walk(func(text string) {
+		b.WriteString(text)
+	}, func(m Marker, raw string) {
+		if len(m.Results) == 0 || m.Kind == Link || m.Kind == URL {
+			b.WriteString(raw)
+			return
+		}
+		for _, i := range m.Results {
+			fmt.Fprintf(&b, "[^%d]", i+1)
+			used = appendUnique(used, i)
+		}
+	})
+
+	out := strings.TrimRight(b.String(), " \t\r\n")
+	if len(used) == 0 {
+		return out
+	}
+	b.Reset()
+	b.WriteString(out)
+	b.WriteString("\n")
+	for _, i := range used {
+		r := d.Results[i]
+		title := r.Title
+		if title == "" {
+			title = r.URL
+		}
+		fmt.Fprintf(&b, "\n[^%d]: [%s](%s)", i+1, escapeMarkdown(title), r.URL)
+	}
+	return b.String()
+}
+
+// HTML returns the text as HTML paragraphs with every resolved citation
+// linked to its source, followed by an ordered list of the cited sources.
+func (d *Document) HTML() string {
+	var b strings.Builder
+	var used []int
+	d.walk(func(text string) {
+		b.WriteString(html.EscapeString(text))
+	}, func(m Marker, raw string) {
+		switch {
+		case m.Kind == URL:
+			fmt.Fprintf(&b, `<a href="%s">%s</a>`, safeURL(m.Label), html.EscapeString(m.Label))
+		case m.Kind == Link:
+			href := raw[strings.LastIndex(raw, "(")+1 : len(raw)-1]
+			fmt.Fprintf(&b, `<a href="%s">%s</a>`, safeURL(href), html.EscapeString(m.Label))
+		case len(m.Results) == 0:
+			b.WriteString(html.EscapeString(raw))
+		default:
+			for _, i := range m.Results {
+				r := d.Results[i]
+				fmt.Fprintf(&b, `<sup class="citation"><a href="%s" title="%s">[%d]</a></sup>`,
+					safeURL(r.URL), html.EscapeString(r.Title), i+1)
+				used = appendUnique(used, i)
+			}
+		}
+	})
+
+	var out strings.Builder
+	for _, para := range strings.Split(strings.ReplaceAll(b.String(), "\r\n", "\n"), "\n\n") {
+		if para = strings.TrimSpace(para); para != "" {
+			out.WriteString("<p>" + strings.ReplaceAll(para, "\n", "<br>\n") + "</p>\n")
+		}
+	}
+	if len(used) > 0 {
+		out.WriteString(`<ol class="sources">` + "\n")
+		for _, i := range used {
+			r := d.Results[i]
+			title := r.Title
+			if title == "" {
+				title = r.URL
+			}
+			fmt.Fprintf(&out, `<li value="%d"><a href="%s">%s</a></li>`+"\n", i+1, safeURL(r.URL), html.EscapeString(title))
+		}
+		out.WriteString("</ol>\n")
+	}
+	return out.String()
+}
+
+// walk calls text for the plain parts of the document and marker for each
+// marker, skipping footnote definitions.
+func (d *Document) walk(text func(string), marker func(m Marker, raw string)) {
+	pos := 0
+	plain := func(end int) {
+		for pos < end {
+			next := end
+			for _, r := range d.defs {
+				if pos >= r[0] && pos < r[1] {
+					pos = r[1]
+					next = -1
+					break
+				}
+				if r[0] > pos && r[0] < next {
+					next = r[0]
+				}
+			}
+			if next < 0 {
+				continue
+			}
+			text(d.Text[pos:next])
+			pos = next
+		}
+	}
+	for _, m := range d.Markers {
+		plain(m.Start)
+		marker(m, d.Text[m.Start:m.End])
+		pos = m.End
+	}
+	plain(len(d.Text))
+}
+
+func safeURL(u string) string {
+	lower := strings.ToLower(u)
+	if !strings.HasPrefix(lower, "https://") && !strings.HasPrefix(lower, "http://") {
+		return "#"
+	}
+	return html.EscapeString(u)
+}
+
+func escapeMarkdown(s string) string {
+	return strings.NewReplacer("[", `\[`, "]", `\]`).Replace(s)
+}
@@ -2,6 +2,7 @@ package valyu
 
 import (
 	"fmt"
+	"log/slog"
 	"net/http"
 	"os"
 	"time"
@@ -20,10 +21,27 @@ const (
 	DefaultTimeout = 30 * time.Second
 )
 
+// Version reports the version of this module as recorded in the binary's
+// build info, or "devel" for local builds. It is sent in the User-Agent.
+func Version() string {
+	return api.Version()
+}
+
 type Client struct {
-	baseURL    string
-	apiKey     string
-	httpClient *http.Client
+	baseURL      string
+	apiKey       string
+	httpClient   *http.Client
+	timeout      *time.Duration
+	transport    transportConfig
+	userAgent    string
+	retry        api.RetryPolicy
+	checkSuccess bool
+	middleware   []api.Middleware
+	logger       *slog.Logger
+	logLevel     slog.Level
+	debug        bool
+	limit        api.Limit
+	groupLimits  map[string]api.Limit
 
 	Search       *search.Service
 	Answer       *answer.Service
@@ -42,18 +60,36 @@ func New(apiKey string, opts ...Option) (*Client, error) {
 	}
 
 	c := &Client{
-		baseURL: DefaultBaseURL,
-		apiKey:  apiKey,
-		httpClient: &http.Client{
-			Timeout: DefaultTimeout,
-		},
+		baseURL:      DefaultBaseURL,
+		apiKey:       apiKey,
+		checkSuccess: true,
+		logLevel:     slog.LevelInfo,
 	}
 
 	for _, opt := range opts {
 		opt(c)
 	}
 
+	httpClient, err := c.buildHTTPClient()
+	if err != nil {
+		return nil, err
+	}
+	c.httpClient = httpClient
+
 	apiClient := api.New(c.baseURL, c.apiKey, c.httpClient)
+	apiClient.UserAgent = api.DefaultUserAgent()
+	if c.userAgent != "" {
+		apiClient.UserAgent += " " + c.userAgent
+	}
+	apiClient.Retry = c.retry
+	apiClient.CheckSuccess = c.checkSuccess
+	apiClient.Middleware = c.middleware
+	apiClient.Logger = c.logger
+	apiClient.LogLevel = c.logLevel
+	apiClient.LogBodies = c.debug
+	if c.limit != (api.Limit{}) || len(c.groupLimits) > 0 {
+		apiClient.Limiter = api.NewLimiter(c.limit, c.groupLimits)
+	}
 
 	c.Search = search.New(apiClient)
 	c.Answer = answer.New(apiClient)
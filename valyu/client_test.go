@@ -0,0 +1,48 @@
+package valyu
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Veri5ied/valyu-go/valyu/common"
+	"github.com/Veri5ied/valyu-go/valyu/search"
+)
+
+func TestSuccessCheck(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"success":false,"error":"no results","tx_id":"tx-1","results":[{"title":"Partial","url":"https://a.com"}]}`)
+	}))
+	defer srv.Close()
+
+	c, err := New("key", WithBaseURL(srv.URL), WithHTTPClient(srv.Client()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.Search.Search(context.Background(), "q", nil)
+	respErr, ok := common.AsResponseError(err)
+	if !ok {
+		t.Fatalf("Search = %v, want a *common.ResponseError", err)
+	}
+	resp, ok := respErr.Response.(*search.Response)
+	if !ok {
+		t.Fatalf("Response = %T, want *search.Response", respErr.Response)
+	}
+	if respErr.Message != "no results" || respErr.TxID != "tx-1" || len(resp.Results) != 1 || resp.Results[0].Title != "Partial" {
+		t.Errorf("ResponseError = %+v with response %+v", respErr, resp)
+	}
+
+	c, err = New("key", WithBaseURL(srv.URL), WithHTTPClient(srv.Client()), WithSuccessCheck(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err = c.Search.Search(context.Background(), "q", nil)
+	if err != nil {
+		t.Fatalf("Search with the check disabled = %v", err)
+	}
+	if resp.Success || resp.Error != "no results" || len(resp.Results) != 1 {
+		t.Errorf("response = %+v, want the decoded failure", resp)
+	}
+}
@@ -0,0 +1,110 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Sentinel errors matched by APIError through errors.Is.
+var (
+	ErrUnauthorized        = errors.New("valyu: unauthorized")
+	ErrRateLimited         = errors.New("valyu: rate limited")
+	ErrInsufficientCredits = errors.New("valyu: insufficient credits")
+	ErrNotFound            = errors.New("valyu: not found")
+	ErrValidation          = errors.New("valyu: invalid request")
+	ErrServer              = errors.New("valyu: server error")
+)
+
+// APIError is returned for any response with an HTTP status of 400 or above.
+type APIError struct {
+	StatusCode int           `json:"status_code"`
+	Code       string        `json:"code,omitempty"`
+	Message    string        `json:"message"`
+	RequestID  string        `json:"request_id,omitempty"`
+	RetryAfter time.Duration `json:"retry_after,omitempty"`
+	Body       []byte        `json:"-"`
+}
+
+func (e *APIError) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = http.StatusText(e.StatusCode)
+	}
+	if e.Code != "" {
+		msg = fmt.Sprintf("valyu: %s (status: %d) - %s", e.Code, e.StatusCode, msg)
+	} else {
+		msg = fmt.Sprintf("valyu: error (status: %d) - %s", e.StatusCode, msg)
+	}
+	if e.RequestID != "" {
+		msg += " (request id: " + e.RequestID + ")"
+	}
+	return msg
+}
+
+// Kind returns the sentinel error that classifies e, or nil if none applies.
+func (e *APIError) Kind() error {
+	code := strings.ToLower(e.Code)
+	switch {
+	case strings.Contains(code, "credit"), e.StatusCode == http.StatusPaymentRequired:
+		return ErrInsufficientCredits
+	case e.StatusCode == http.StatusUnauthorized, e.StatusCode == http.StatusForbidden:
+		return ErrUnauthorized
+	case e.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	case e.StatusCode == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case e.StatusCode == http.StatusBadRequest, e.StatusCode == http.StatusUnprocessableEntity:
+		return ErrValidation
+	case e.StatusCode >= 500:
+		return ErrServer
+	}
+	return nil
+}
+
+func (e *APIError) Is(target error) bool {
+	kind := e.Kind()
+	return kind != nil && kind == target
+}
+
+// Temporary reports whether the request may succeed if retried later.
+func (e *APIError) Temporary() bool {
+	kind := e.Kind()
+	return kind == ErrRateLimited || kind == ErrServer
+}
+
+// AsAPIError unwraps err to an *APIError if it contains one.
+func AsAPIError(err error) (*APIError, bool) {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr, true
+	}
+	return nil, false
+}
+
+// ResponseError is returned when the API answers with a success status code
+// but reports "success": false in the body. Response holds the decoded body,
+// e.g. a *search.Response.
+type ResponseError struct {
+	Message  string      `json:"message"`
+	TxID     string      `json:"tx_id,omitempty"`
+	Response interface{} `json:"-"`
+}
+
+func (e *ResponseError) Error() string {
+	if e.Message == "" {
+		return "valyu: request failed"
+	}
+	return "valyu: request failed - " + e.Message
+}
+
+// AsResponseError unwraps err to a *ResponseError if it contains one.
+func AsResponseError(err error) (*ResponseError, bool) {
+	var respErr *ResponseError
+	if errors.As(err, &respErr) {
+		return respErr, true
+	}
+	return nil, false
+}
@@ -0,0 +1,85 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestAPIErrorKind(t *testing.T) {
+	tests := []struct {
+		name          string
+		err           APIError
+		want          error
+		wantTemporary bool
+	}{
+		{"bad request", APIError{StatusCode: 400}, ErrValidation, false},
+		{"unauthorized", APIError{StatusCode: 401}, ErrUnauthorized, false},
+		{"payment required", APIError{StatusCode: 402}, ErrInsufficientCredits, false},
+		{"forbidden", APIError{StatusCode: 403}, ErrUnauthorized, false},
+		{"not found", APIError{StatusCode: 404}, ErrNotFound, false},
+		{"conflict", APIError{StatusCode: 409}, nil, false},
+		{"unprocessable", APIError{StatusCode: 422}, ErrValidation, false},
+		{"rate limited", APIError{StatusCode: 429}, ErrRateLimited, true},
+		{"internal", APIError{StatusCode: 500}, ErrServer, true},
+		{"unavailable", APIError{StatusCode: 503}, ErrServer, true},
+		{"credits code beats status", APIError{StatusCode: 403, Code: "INSUFFICIENT_CREDITS"}, ErrInsufficientCredits, false},
+		{"credit code on bad request", APIError{StatusCode: 400, Code: "credit_limit"}, ErrInsufficientCredits, false},
+		{"other code keeps status", APIError{StatusCode: 429, Code: "too_many"}, ErrRateLimited, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := tt.err
+			if got := e.Kind(); got != tt.want {
+				t.Errorf("Kind() = %v, want %v", got, tt.want)
+			}
+			if got := e.Temporary(); got != tt.wantTemporary {
+				t.Errorf("Temporary() = %v, want %v", got, tt.wantTemporary)
+			}
+			wrapped := fmt.Errorf("search: %w", &e)
+			for _, sentinel := range []error{ErrUnauthorized, ErrRateLimited, ErrInsufficientCredits, ErrNotFound, ErrValidation, ErrServer} {
+				if got, want := errors.Is(wrapped, sentinel), sentinel == tt.want; got != want {
+					t.Errorf("errors.Is(err, %v) = %v, want %v", sentinel, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestAPIErrorMessage(t *testing.T) {
+	tests := []struct {
+		err  APIError
+		want string
+	}{
+		{APIError{StatusCode: 404}, "valyu: error (status: 404) - Not Found"},
+		{APIError{StatusCode: 400, Message: "query is required"}, "valyu: error (status: 400) - query is required"},
+		{APIError{StatusCode: 402, Code: "insufficient_credits", Message: "top up"}, "valyu: insufficient_credits (status: 402) - top up"},
+		{APIError{StatusCode: 500, RequestID: "req-1"}, "valyu: error (status: 500) - Internal Server Error (request id: req-1)"},
+	}
+	for _, tt := range tests {
+		if got := tt.err.Error(); got != tt.want {
+			t.Errorf("Error() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestAsErrors(t *testing.T) {
+	apiErr := &APIError{StatusCode: 500}
+	if got, ok := AsAPIError(fmt.Errorf("wrapped: %w", apiErr)); !ok || got != apiErr {
+		t.Errorf("AsAPIError = %v, %v; want the wrapped error", got, ok)
+	}
+	if _, ok := AsAPIError(errors.New("other")); ok {
+		t.Error("AsAPIError matched an unrelated error")
+	}
+
+	respErr := &ResponseError{Message: "no results"}
+	if got, ok := AsResponseError(fmt.Errorf("wrapped: %w", respErr)); !ok || got != respErr {
+		t.Errorf("AsResponseError = %v, %v; want the wrapped error", got, ok)
+	}
+	if got := respErr.Error(); got != "valyu: request failed - no results" {
+		t.Errorf("Error() = %q", got)
+	}
+	if got := (&ResponseError{}).Error(); got != "valyu: request failed" {
+		t.Errorf("Error() with no message = %q", got)
+	}
+}
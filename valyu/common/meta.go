@@ -0,0 +1,33 @@
+package common
+
+import (
+	"net/http"
+	"time"
+)
+
+// ResponseMeta describes the HTTP envelope of a call. Fill it by passing
+// option.WithResponseInto to any service method.
+type ResponseMeta struct {
+	StatusCode   int
+	Header       http.Header
+	RequestID    string
+	RateLimit    RateLimit
+	ServerTiming []ServerTiming
+	Attempts     int
+	Duration     time.Duration
+}
+
+// RateLimit holds the quota reported by the X-RateLimit-* or RateLimit-*
+// headers. Fields are -1 or zero when the server did not send them.
+type RateLimit struct {
+	Limit     int
+	Remaining int
+	Reset     time.Time
+}
+
+// ServerTiming is one metric of the Server-Timing header.
+type ServerTiming struct {
+	Name        string
+	Duration    time.Duration
+	Description string
+}
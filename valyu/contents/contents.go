@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/Veri5ied/valyu-go/valyu/internal/api"
+	"github.com/Veri5ied/valyu-go/valyu/option"
 )
 
 type Service struct {
@@ -14,7 +15,7 @@ func New(client *api.Client) *Service {
 	return &Service{client: client}
 }
 
-func (s *Service) Get(ctx context.Context, urls []string, opts *Options) (*Response, error) {
+func (s *Service) Get(ctx context.Context, urls []string, opts *Options, reqOpts ...option.RequestOption) (*Response, error) {
 	req := struct {
 		URLs []string `json:"urls"`
 		*Options
@@ -24,7 +25,7 @@ func (s *Service) Get(ctx context.Context, urls []string, opts *Options) (*Respo
 	}
 
 	var resp Response
-	if err := s.client.Post(ctx, "/contents", req, &resp); err != nil {
+	if err := s.client.Post(ctx, "/contents", req, &resp, reqOpts...); err != nil {
 		return nil, err
 	}
 	return &resp, nil
@@ -3,6 +3,8 @@ package contents
 import "github.com/Veri5ied/valyu-go/valyu/common"
 
 type Options struct {
+	// Summary is true, an instruction string, or a JSON Schema such as one
+	// from schema.For to extract structured data.
 	Summary         interface{}           `json:"summary,omitempty"`
 	ExtractEffort   common.ExtractEffort  `json:"extract_effort,omitempty"`
 	ResponseLength  common.ResponseLength `json:"response_length,omitempty"`
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/Veri5ied/valyu-go/valyu/internal/api"
+	"github.com/Veri5ied/valyu-go/valyu/option"
 )
 
 type Service struct {
@@ -14,9 +15,9 @@ func New(client *api.Client) *Service {
 	return &Service{client: client}
 }
 
-func (s *Service) List(ctx context.Context) (*ListResponse, error) {
+func (s *Service) List(ctx context.Context, reqOpts ...option.RequestOption) (*ListResponse, error) {
 	var resp ListResponse
-	if err := s.client.Get(ctx, "/datasources", &resp); err != nil {
+	if err := s.client.Get(ctx, "/datasources", &resp, reqOpts...); err != nil {
 		return nil, err
 	}
 	return &resp, nil
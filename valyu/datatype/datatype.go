@@ -0,0 +1,193 @@
+// Package datatype decodes the polymorphic Content of search and contents
+// results. Depending on the result's data type and source, Content holds
+// text, JSON rows or some other JSON value; Text, JSON and Rows read it
+// in a given shape, and a Registry maps data types and source IDs to the Go
+// types their content decodes into.
+package datatype
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Data types reported in Result.DataType.
+const (
+	Unstructured = "unstructured"
+	Structured   = "structured"
+)
+
+// ErrNotRows is returned by Rows for content that is not tabular.
+var ErrNotRows = errors.New("valyu: content is not a list of rows")
+
+// Text returns content as text: strings as they are, anything else as
+// compact JSON.
+func Text(content interface{}) string {
+	switch v := content.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	}
+	b, err := json.Marshal(content)
+	if err != nil {
+		return fmt.Sprint(content)
+	}
+	return string(b)
+}
+
+// ContentDecoder is implemented by types that decode content themselves,
+// e.g. to accept both text and JSON. JSON and Decode use it when present.
+type ContentDecoder interface {
+	DecodeContent(content interface{}) error
+}
+
+// JSON decodes content into v, which must be a pointer. String content is
+// parsed as JSON unless v is a *string or a ContentDecoder.
+func JSON(content interface{}, v interface{}) error {
+	if d, ok := v.(ContentDecoder); ok {
+		return d.DecodeContent(content)
+	}
+	if s, ok := content.(string); ok {
+		if sp, ok := v.(*string); ok {
+			*sp = s
+			return nil
+		}
+		return json.Unmarshal([]byte(s), v)
+	}
+	b, err := json.Marshal(content)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// As decodes content into a value of type T, like JSON.
+func As[T any](content interface{}) (T, error) {
+	var v T
+	err := JSON(content, &v)
+	return v, err
+}
+
+// DecodeAs decodes content into a T the way Registry.Decode does for a
+// registered T, normalizing content for slice types with Rows first, but
+// without a registration:
+//
+//	bars, err := datatype.DecodeAs[[]datatype.PriceBar](result.Content)
+func DecodeAs[T any](content interface{}) (T, error) {
+	var v T
+	err := decode(content, &v)
+	return v, err
+}
+
+// decode decodes content into the value v points to, normalizing it with
+// Rows first if that value is a slice.
+func decode(content interface{}, v interface{}) error {
+	if reflect.TypeOf(v).Elem().Kind() == reflect.Slice {
+		if rows, err := Rows(content); err == nil {
+			content = rows
+		}
+	}
+	return JSON(content, v)
+}
+
+// rowKeys are the fields that commonly wrap a list of rows.
+var rowKeys = []string{"rows", "data", "results", "items", "records"}
+
+// Rows returns structured content as a list of JSON objects. It accepts
+// an array of objects, an object wrapping one under a key such as "rows"
+// or "data", a column-oriented {"columns": [...], "rows": [[...], ...]}
+// table, a single object (one row), or a string holding any of these.
+// Other content yields ErrNotRows; nil content yields no rows.
+func Rows(content interface{}) ([]map[string]interface{}, error) {
+	switch v := content.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		var parsed interface{}
+		if err := json.Unmarshal([]byte(strings.TrimSpace(v)), &parsed); err != nil {
+			return nil, ErrNotRows
+		}
+		if _, ok := parsed.(string); ok {
+			return nil, ErrNotRows
+		}
+		return Rows(parsed)
+	case []map[string]interface{}:
+		return v, nil
+	case []interface{}:
+		rows := make([]map[string]interface{}, 0, len(v))
+		for _, item := range v {
+			row, ok := item.(map[string]interface{})
+			if !ok {
+				return nil, ErrNotRows
+			}
+			rows = append(rows, row)
+		}
+		return rows, nil
+	case map[string]interface{}:
+		if rows, ok := columnRows(v); ok {
+			return rows, nil
+		}
+		for _, key := range rowKeys {
+			if inner, ok := v[key].([]interface{}); ok {
+				return Rows(inner)
+			}
+		}
+		return []map[string]interface{}{v}, nil
+	}
+
+	// Typed content, e.g. from a caller that built a Result by hand.
+	var generic interface{}
+	if err := JSON(content, &generic); err != nil {
+		return nil, ErrNotRows
+	}
+	if _, ok := generic.(map[string]interface{}); !ok {
+		if _, ok := generic.([]interface{}); !ok {
+			return nil, ErrNotRows
+		}
+	}
+	return Rows(generic)
+}
+
+// columnRows zips a {"columns": [...], "rows"|"data": [[...], ...]} table
+// into objects.
+func columnRows(v map[string]interface{}) ([]map[string]interface{}, bool) {
+	cols, ok := v["columns"].([]interface{})
+	if !ok {
+		return nil, false
+	}
+	names := make([]string, len(cols))
+	for i, c := range cols {
+		switch c := c.(type) {
+		case string:
+			names[i] = c
+		case map[string]interface{}:
+			names[i], _ = c["name"].(string)
+		}
+		if names[i] == "" {
+			return nil, false
+		}
+	}
+	data, ok := v["rows"].([]interface{})
+	if !ok {
+		if data, ok = v["data"].([]interface{}); !ok {
+			return nil, false
+		}
+	}
+
+	rows := make([]map[string]interface{}, 0, len(data))
+	for _, item := range data {
+		cells, ok := item.([]interface{})
+		if !ok || len(cells) != len(names) {
+			return nil, false
+		}
+		row := make(map[string]interface{}, len(names))
+		for i, name := range names {
+			row[name] = cells[i]
+		}
+		rows = append(rows, row)
+	}
+	return rows, true
+}
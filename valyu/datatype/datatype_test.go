@@ -0,0 +1,135 @@
+package datatype
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestRows(t *testing.T) {
+	a := map[string]interface{}{"x": 1.0}
+	b := map[string]interface{}{"x": 2.0}
+	tests := []struct {
+		name    string
+		content interface{}
+		want    []map[string]interface{}
+		wantErr error
+	}{
+		{"nil", nil, nil, nil},
+		{"array", []interface{}{a, b}, []map[string]interface{}{a, b}, nil},
+		{"wrapped", map[string]interface{}{"data": []interface{}{a}}, []map[string]interface{}{a}, nil},
+		{"single object", a, []map[string]interface{}{a}, nil},
+		{"json string", `{"rows":[{"x":1},{"x":2}]}`, []map[string]interface{}{a, b}, nil},
+		{
+			"column table",
+			map[string]interface{}{"columns": []interface{}{"x"}, "rows": []interface{}{[]interface{}{1.0}, []interface{}{2.0}}},
+			[]map[string]interface{}{a, b},
+			nil,
+		},
+		{"text", "plain text", nil, ErrNotRows},
+		{"array of scalars", []interface{}{1.0}, nil, ErrNotRows},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Rows(tt.content)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("rows = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDefaultDecode(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   string
+		dataType string
+		content  interface{}
+		want     interface{}
+		wantErr  error
+	}{
+		{
+			name:     "unstructured text",
+			dataType: Unstructured,
+			content:  "hello",
+			want:     "hello",
+		},
+		{
+			name:    "stock bars",
+			source:  SourceStocks,
+			content: map[string]interface{}{"data": []interface{}{map[string]interface{}{"symbol": "AAPL", "date": "2024-01-02", "open": 1.0, "high": 2.0, "low": 0.5, "close": 1.5, "volume": 100.0}}},
+			want:    []PriceBar{{Symbol: "AAPL", Date: "2024-01-02", Open: 1, High: 2, Low: 0.5, Close: 1.5, Volume: 100}},
+		},
+		{
+			name:    "crypto column table with timestamps",
+			source:  "VALYU/valyu-crypto",
+			content: `{"columns":["timestamp","close"],"rows":[["2024-01-02T00:00:00Z",42000],[1704240000,43000]]}`,
+			want:    []PriceBar{{Date: "2024-01-02T00:00:00Z", Close: 42000}, {Date: "1704240000", Close: 43000}},
+		},
+		{
+			name:     "paper text",
+			source:   SourceArxiv,
+			dataType: Unstructured,
+			content:  "# Attention Is All You Need\n...",
+			want:     Paper{Content: "# Attention Is All You Need\n..."},
+		},
+		{
+			name:    "paper record",
+			source:  SourcePubMed,
+			content: map[string]interface{}{"title": "T", "authors": []interface{}{"A", "B"}, "doi": "10.1/x"},
+			want:    Paper{Title: "T", Authors: []string{"A", "B"}, DOI: "10.1/x"},
+		},
+		{
+			name:    "paper record as string",
+			source:  SourceArxiv,
+			content: `{"title":"T","abstract":"short"}`,
+			want:    Paper{Title: "T", Abstract: "short"},
+		},
+		{
+			name:     "unregistered",
+			source:   "acme/unknown",
+			dataType: Structured,
+			content:  map[string]interface{}{},
+			wantErr:  ErrNotRegistered,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Default.Decode(tt.source, tt.dataType, tt.content)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Decode = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeAs(t *testing.T) {
+	bars, err := DecodeAs[[]PriceBar](map[string]interface{}{
+		"columns": []interface{}{"date", "close"},
+		"data":    []interface{}{[]interface{}{"2024-01-02", 10.0}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []PriceBar{{Date: "2024-01-02", Close: 10}}; !reflect.DeepEqual(bars, want) {
+		t.Errorf("bars = %#v, want %#v", bars, want)
+	}
+
+	paper, err := DecodeAs[Paper]("just text")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if paper.Content != "just text" {
+		t.Errorf("paper = %#v, want Content %q", paper, "just text")
+	}
+
+	if _, err := DecodeAs[[]PriceBar]("not rows"); err == nil {
+		t.Error("DecodeAs[[]PriceBar] of text succeeded, want error")
+	}
+}
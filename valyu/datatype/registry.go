@@ -0,0 +1,113 @@
+package datatype
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// ErrNotRegistered is returned by Decode when no type is registered for a
+// result's source or data type.
+var ErrNotRegistered = errors.New("valyu: no type registered for content")
+
+// Registry maps source IDs (such as "valyu/valyu-arxiv" or a domain) and
+// data types to the Go types their content decodes into. A source
+// registration takes precedence over a data type one. It is safe for
+// concurrent use.
+type Registry struct {
+	mu        sync.RWMutex
+	sources   map[string]reflect.Type
+	dataTypes map[string]reflect.Type
+}
+
+// NewRegistry returns an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		sources:   make(map[string]reflect.Type),
+		dataTypes: make(map[string]reflect.Type),
+	}
+}
+
+// Default is the registry used by the Decode methods of search.Result and
+// contents.Result. It maps Unstructured content to string, market data
+// sources such as SourceStocks to []PriceBar and paper sources such as
+// SourceArxiv to Paper; register your own sources and data types on it:
+//
+//	type Bar struct {
+//		Date  string  `json:"date"`
+//		Close float64 `json:"close"`
+//	}
+//
+//	datatype.Default.RegisterSource("acme/daily-prices", []Bar{})
+//
+//	v, err := result.Decode()
+//	bars := v.([]Bar)
+var Default = NewRegistry()
+
+func init() {
+	Default.RegisterDataType(Unstructured, "")
+}
+
+// RegisterSource maps a source ID, matched case-insensitively, to the type
+// of prototype. A later registration replaces an earlier one. It panics if
+// prototype is nil.
+func (r *Registry) RegisterSource(source string, prototype interface{}) {
+	r.register(r.sources, source, prototype)
+}
+
+// RegisterDataType maps a data type, matched case-insensitively, to the
+// type of prototype. A later registration replaces an earlier one. It
+// panics if prototype is nil.
+func (r *Registry) RegisterDataType(dataType string, prototype interface{}) {
+	r.register(r.dataTypes, dataType, prototype)
+}
+
+func (r *Registry) register(m map[string]reflect.Type, key string, prototype interface{}) {
+	if prototype == nil {
+		panic("datatype: nil prototype for " + key)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	m[strings.ToLower(key)] = reflect.TypeOf(prototype)
+}
+
+// Lookup returns the type registered for source, or else for dataType.
+func (r *Registry) Lookup(source, dataType string) (reflect.Type, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if t, ok := r.sources[strings.ToLower(source)]; ok && source != "" {
+		return t, true
+	}
+	if t, ok := r.dataTypes[strings.ToLower(dataType)]; ok && dataType != "" {
+		return t, true
+	}
+	return nil, false
+}
+
+// Decode decodes content into a new value of the type registered for
+// source or dataType and returns it, e.g. a []Bar for the registration
+// above. Content for a slice type is first normalized with Rows, so
+// wrapped and column-oriented tables decode too. It returns
+// ErrNotRegistered if there is no registration.
+func (r *Registry) Decode(source, dataType string, content interface{}) (interface{}, error) {
+	t, ok := r.Lookup(source, dataType)
+	if !ok {
+		return nil, fmt.Errorf("%w (source %q, data type %q)", ErrNotRegistered, source, dataType)
+	}
+	v := reflect.New(t)
+	if err := decode(content, v.Interface()); err != nil {
+		return nil, fmt.Errorf("valyu: decoding %s content as %s: %w", first(source, dataType), t, err)
+	}
+	return v.Elem().Interface(), nil
+}
+
+func first(ss ...string) string {
+	for _, s := range ss {
+		if s != "" {
+			return s
+		}
+	}
+	return ""
+}
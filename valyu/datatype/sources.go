@@ -0,0 +1,86 @@
+package datatype
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Sources with built-in registrations in Default.
+const (
+	SourceStocks = "valyu/valyu-stocks-US"
+	SourceCrypto = "valyu/valyu-crypto"
+	SourceArxiv  = "valyu/valyu-arxiv"
+	SourcePubMed = "valyu/valyu-pubmed"
+)
+
+// PriceBar is one row of market data, as returned by SourceStocks and
+// SourceCrypto; Decode returns a []PriceBar for them. Date is taken from a
+// "date", "timestamp", "datetime" or "time" field, whichever is present.
+type PriceBar struct {
+	Symbol string  `json:"symbol,omitempty"`
+	Date   string  `json:"date"`
+	Open   float64 `json:"open"`
+	High   float64 `json:"high"`
+	Low    float64 `json:"low"`
+	Close  float64 `json:"close"`
+	Volume float64 `json:"volume,omitempty"`
+}
+
+func (b *PriceBar) UnmarshalJSON(data []byte) error {
+	type plain PriceBar
+	var v struct {
+		plain
+		Timestamp json.RawMessage `json:"timestamp"`
+		Datetime  json.RawMessage `json:"datetime"`
+		Time      json.RawMessage `json:"time"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*b = PriceBar(v.plain)
+	if b.Date == "" {
+		for _, raw := range []json.RawMessage{v.Timestamp, v.Datetime, v.Time} {
+			if len(raw) > 0 && string(raw) != "null" {
+				b.Date = strings.Trim(string(raw), `"`)
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// Paper is a research paper from SourceArxiv or SourcePubMed. These
+// sources usually send the paper's text, which decodes into Content;
+// structured records fill the other fields as well.
+type Paper struct {
+	Title         string   `json:"title,omitempty"`
+	Authors       []string `json:"authors,omitempty"`
+	Abstract      string   `json:"abstract,omitempty"`
+	DOI           string   `json:"doi,omitempty"`
+	PublishedDate string   `json:"published_date,omitempty"`
+	Categories    []string `json:"categories,omitempty"`
+	URL           string   `json:"url,omitempty"`
+	Content       string   `json:"content,omitempty"`
+}
+
+// DecodeContent implements ContentDecoder: a JSON object fills the fields,
+// anything else becomes Content.
+func (p *Paper) DecodeContent(content interface{}) error {
+	type plain Paper
+	if s, ok := content.(string); ok {
+		var v plain
+		if !strings.HasPrefix(strings.TrimSpace(s), "{") || json.Unmarshal([]byte(s), &v) != nil {
+			v = plain{Content: s}
+		}
+		*p = Paper(v)
+		return nil
+	}
+	return JSON(content, (*plain)(p))
+}
+
+func init() {
+	Default.RegisterSource(SourceStocks, []PriceBar{})
+	Default.RegisterSource(SourceCrypto, []PriceBar{})
+	Default.RegisterSource(SourceArxiv, Paper{})
+	Default.RegisterSource(SourcePubMed, Paper{})
+}
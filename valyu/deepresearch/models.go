@@ -34,20 +34,22 @@ type MCPServerConfig struct {
 }
 
 type CreateOptions struct {
-	Query             string                  `json:"query"`
-	Mode              common.DeepResearchMode `json:"mode,omitempty"`
-	OutputFormats     []string                `json:"output_formats,omitempty"`
-	Strategy          string                  `json:"strategy,omitempty"`
-	Search            *SearchConfig           `json:"search,omitempty"`
-	URLs              []string                `json:"urls,omitempty"`
-	Files             []FileAttachment        `json:"files,omitempty"`
-	Deliverables      []interface{}           `json:"deliverables,omitempty"`
-	MCPServers        []MCPServerConfig       `json:"mcp_servers,omitempty"`
-	CodeExecution     *bool                   `json:"code_execution,omitempty"`
-	PreviousReports   []string                `json:"previous_reports,omitempty"`
-	WebhookURL        string                  `json:"webhook_url,omitempty"`
-	BrandCollectionID string                  `json:"brand_collection_id,omitempty"`
-	Metadata          map[string]interface{}  `json:"metadata,omitempty"`
+	Query         string                  `json:"query"`
+	Mode          common.DeepResearchMode `json:"mode,omitempty"`
+	OutputFormats []string                `json:"output_formats,omitempty"`
+	Strategy      string                  `json:"strategy,omitempty"`
+	Search        *SearchConfig           `json:"search,omitempty"`
+	URLs          []string                `json:"urls,omitempty"`
+	Files         []FileAttachment        `json:"files,omitempty"`
+	// Deliverables lists extra outputs; entries may be descriptions or JSON
+	// Schemas built with schema.For.
+	Deliverables      []interface{}          `json:"deliverables,omitempty"`
+	MCPServers        []MCPServerConfig      `json:"mcp_servers,omitempty"`
+	CodeExecution     *bool                  `json:"code_execution,omitempty"`
+	PreviousReports   []string               `json:"previous_reports,omitempty"`
+	WebhookURL        string                 `json:"webhook_url,omitempty"`
+	BrandCollectionID string                 `json:"brand_collection_id,omitempty"`
+	Metadata          map[string]interface{} `json:"metadata,omitempty"`
 }
 
 type CreateResponse struct {
@@ -0,0 +1,30 @@
+package valyu
+
+import "github.com/Veri5ied/valyu-go/valyu/common"
+
+// APIError is returned for HTTP error responses. Use errors.Is with the
+// sentinels below to classify it, or errors.As to read its fields.
+type APIError = common.APIError
+
+// ResponseError is returned when a response body reports "success": false.
+// Its Response field holds the decoded body.
+type ResponseError = common.ResponseError
+
+var (
+	ErrUnauthorized        = common.ErrUnauthorized
+	ErrRateLimited         = common.ErrRateLimited
+	ErrInsufficientCredits = common.ErrInsufficientCredits
+	ErrNotFound            = common.ErrNotFound
+	ErrValidation          = common.ErrValidation
+	ErrServer              = common.ErrServer
+)
+
+// AsAPIError unwraps err to an *APIError if it contains one.
+func AsAPIError(err error) (*APIError, bool) {
+	return common.AsAPIError(err)
+}
+
+// AsResponseError unwraps err to a *ResponseError if it contains one.
+func AsResponseError(err error) (*ResponseError, bool) {
+	return common.AsResponseError(err)
+}
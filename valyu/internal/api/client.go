@@ -6,13 +6,32 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
+	"time"
+
+	"github.com/Veri5ied/valyu-go/valyu/common"
 )
 
 type Client struct {
 	BaseURL    string
 	APIKey     string
 	HTTPClient *http.Client
+	Retry      RetryPolicy
+
+	// CheckSuccess turns 2xx bodies with "success": false into a
+	// *common.ResponseError.
+	CheckSuccess bool
+
+	Middleware []Middleware
+
+	Logger    *slog.Logger
+	LogLevel  slog.Level
+	LogBodies bool
+
+	Limiter *Limiter
+
+	UserAgent string
 }
 
 func New(baseURL, apiKey string, httpClient *http.Client) *Client {
@@ -23,87 +42,308 @@ func New(baseURL, apiKey string, httpClient *http.Client) *Client {
 	}
 }
 
-func (c *Client) Post(ctx context.Context, path string, body interface{}, result interface{}) error {
-	return c.do(ctx, http.MethodPost, path, body, result)
+func (c *Client) Post(ctx context.Context, path string, body interface{}, result interface{}, opts ...RequestOption) error {
+	return c.do(ctx, http.MethodPost, path, body, result, opts)
 }
 
-func (c *Client) Get(ctx context.Context, path string, result interface{}) error {
-	return c.do(ctx, http.MethodGet, path, nil, result)
+func (c *Client) Get(ctx context.Context, path string, result interface{}, opts ...RequestOption) error {
+	return c.do(ctx, http.MethodGet, path, nil, result, opts)
 }
 
-func (c *Client) do(ctx context.Context, method, path string, body interface{}, result interface{}) error {
-	var bodyReader io.Reader
-	if body != nil {
-		jsonBody, err := json.Marshal(body)
-		if err != nil {
-			return fmt.Errorf("marshal request: %w", err)
-		}
-		bodyReader = bytes.NewReader(jsonBody)
-	}
+func (c *Client) do(ctx context.Context, method, path string, body interface{}, result interface{}, opts []RequestOption) error {
+	req := c.newRequest(method, path, body, opts)
+	_, err := c.handle(ctx, req, result)
+	return err
+}
 
-	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, bodyReader)
+func (c *Client) PostStream(ctx context.Context, path string, body interface{}, opts ...RequestOption) (*http.Response, error) {
+	req := c.newRequest(http.MethodPost, path, body, opts)
+	req.Stream = true
+	resp, err := c.handle(ctx, req, nil)
 	if err != nil {
-		return fmt.Errorf("create request: %w", err)
+		return nil, err
 	}
+	return resp.HTTPResponse, nil
+}
 
-	c.setHeaders(req)
+func (c *Client) newRequest(method, path string, body interface{}, opts []RequestOption) *Request {
+	req := &Request{
+		Method:  method,
+		Path:    path,
+		Body:    body,
+		Header:  make(http.Header),
+		BaseURL: c.BaseURL,
+		APIKey:  c.APIKey,
+	}
+	for _, opt := range opts {
+		opt(req)
+	}
+	return req
+}
+
+func (c *Client) roundTrip(ctx context.Context, r *Request, result interface{}) (out *Response, err error) {
+	if r.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.Timeout)
+		defer cancel()
+	}
 
-	resp, err := c.HTTPClient.Do(req)
+	start := time.Now()
+	var status, attempts int
+	var txID string
+	defer func() {
+		c.logRequest(ctx, r.Method, r.Path, status, attempts, start, txID, err)
+	}()
+
+	resp, attempts, err := c.send(ctx, r, "")
+	fillMeta(r.ResponseMeta, resp, attempts, start)
 	if err != nil {
-		return fmt.Errorf("do request: %w", err)
+		return nil, err
 	}
 	defer resp.Body.Close()
+	status = resp.StatusCode
+
+	out = &Response{HTTPResponse: resp, Result: result}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return out, fmt.Errorf("read response: %w", err)
+	}
+	c.dumpResponse(ctx, r.Path, resp, data)
+	resp.Body = io.NopCloser(bytes.NewReader(data))
 
 	if resp.StatusCode >= 400 {
-		return c.handleError(resp)
+		return out, c.handleError(resp)
 	}
 
-	if result != nil {
-		if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
-			return fmt.Errorf("decode response: %w", err)
-		}
+	var meta struct {
+		TxID string `json:"tx_id"`
+	}
+	if json.Unmarshal(data, &meta) == nil {
+		txID = meta.TxID
+	}
+
+	if result == nil {
+		return out, nil
+	}
+
+	if err := json.Unmarshal(data, result); err != nil {
+		return out, fmt.Errorf("decode response: %w", err)
+	}
+
+	if c.CheckSuccess {
+		return out, checkSuccess(data, result)
+	}
+	return out, nil
+}
+
+func (c *Client) stream(ctx context.Context, r *Request) (*Response, error) {
+	cancel := context.CancelFunc(func() {})
+	if r.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, r.Timeout)
+	}
+
+	start := time.Now()
+	resp, attempts, err := c.send(ctx, r, "text/event-stream")
+	fillMeta(r.ResponseMeta, resp, attempts, start)
+	if err != nil {
+		cancel()
+		c.logRequest(ctx, r.Method, r.Path, 0, attempts, start, "", err)
+		return nil, err
+	}
+	if resp.StatusCode >= 400 {
+		// Error statuses carry a JSON body, not a stream, so fail here where
+		// middleware and logging see it rather than in the caller.
+		err := c.handleError(resp)
+		resp.Body.Close()
+		cancel()
+		c.logRequest(ctx, r.Method, r.Path, resp.StatusCode, attempts, start, "", err)
+		return &Response{HTTPResponse: resp}, err
 	}
+	resp.Body = &releaseBody{ReadCloser: resp.Body, release: cancel}
+	c.logRequest(ctx, r.Method, r.Path, resp.StatusCode, attempts, start, "", nil)
+	c.dumpStream(ctx, r.Path, resp)
+	return &Response{HTTPResponse: resp}, nil
+}
+
+func copyHeader(dst, src http.Header) {
+	for k, vs := range src {
+		dst[k] = append([]string(nil), vs...)
+	}
+}
 
-	return nil
+func checkSuccess(data []byte, result interface{}) error {
+	var status struct {
+		Success *bool  `json:"success"`
+		Error   string `json:"error"`
+		Message string `json:"message"`
+		TxID    string `json:"tx_id"`
+	}
+	if json.Unmarshal(data, &status) != nil || status.Success == nil || *status.Success {
+		return nil
+	}
+	msg := status.Error
+	if msg == "" {
+		msg = status.Message
+	}
+	return &common.ResponseError{
+		Message:  msg,
+		TxID:     status.TxID,
+		Response: result,
+	}
 }
 
-func (c *Client) PostStream(ctx context.Context, path string, body interface{}) (*http.Response, error) {
-	var bodyReader io.Reader
-	if body != nil {
-		jsonBody, err := json.Marshal(body)
+// send performs the request, retrying transient failures according to the
+// client's RetryPolicy. The returned response may carry an error status if
+// the final attempt failed; the caller owns its body. It also reports how many
+// attempts were made.
+func (c *Client) send(ctx context.Context, r *Request, accept string) (*http.Response, int, error) {
+	method, path := r.Method, r.Path
+
+	var jsonBody []byte
+	if r.Body != nil {
+		b, err := json.Marshal(r.Body)
 		if err != nil {
-			return nil, fmt.Errorf("marshal request: %w", err)
+			return nil, 0, fmt.Errorf("marshal request: %w", err)
 		}
-		bodyReader = bytes.NewReader(jsonBody)
+		jsonBody = b
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL+path, bodyReader)
-	if err != nil {
-		return nil, fmt.Errorf("create request: %w", err)
+	policy := c.Retry.normalize()
+	idempotencyKey := r.IdempotencyKey
+	if idempotencyKey == "" && method == http.MethodPost && policy.MaxAttempts > 1 {
+		idempotencyKey = newIdempotencyKey()
 	}
 
-	c.setHeaders(req)
-	req.Header.Set("Accept", "text/event-stream")
+	for attempt := 1; ; attempt++ {
+		var bodyReader io.Reader
+		if jsonBody != nil {
+			bodyReader = bytes.NewReader(jsonBody)
+		}
 
-	return c.HTTPClient.Do(req)
+		req, err := http.NewRequestWithContext(ctx, method, r.BaseURL+path, bodyReader)
+		if err != nil {
+			return nil, attempt, fmt.Errorf("create request: %w", err)
+		}
+
+		c.setHeaders(req, r.APIKey)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		if idempotencyKey != "" {
+			req.Header.Set("Idempotency-Key", idempotencyKey)
+		}
+		copyHeader(req.Header, r.Header)
+		c.dumpRequest(ctx, req, jsonBody)
+
+		release, err := c.Limiter.Acquire(ctx, path)
+		if err != nil {
+			return nil, attempt, fmt.Errorf("rate limit: %w", err)
+		}
+
+		resp, err := c.HTTPClient.Do(req)
+		last := attempt >= policy.MaxAttempts
+		if err != nil {
+			release()
+			if last || ctx.Err() != nil || !policy.RetryableError(err) {
+				return nil, attempt, fmt.Errorf("do request: %w", err)
+			}
+			delay := policy.backoff(attempt)
+			c.logRetry(ctx, method, path, attempt, delay, 0, err)
+			if err := sleep(ctx, delay); err != nil {
+				return nil, attempt, fmt.Errorf("do request: %w", err)
+			}
+			continue
+		}
+
+		resp.Body = &releaseBody{ReadCloser: resp.Body, release: release}
+
+		if last || !policy.retryableStatus(resp.StatusCode) {
+			return resp, attempt, nil
+		}
+
+		delay := policy.backoff(attempt)
+		if d, ok := retryAfter(resp.Header, time.Now()); ok {
+			if d > policy.MaxDelay {
+				// The server asked for a longer pause than we are willing to
+				// wait, so surface the failure instead.
+				return resp, attempt, nil
+			}
+			delay = d
+		}
+
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+		resp.Body.Close()
+
+		c.logRetry(ctx, method, path, attempt, delay, resp.StatusCode, nil)
+		if err := sleep(ctx, delay); err != nil {
+			return nil, attempt, fmt.Errorf("do request: %w", err)
+		}
+	}
 }
 
-func (c *Client) setHeaders(req *http.Request) {
+func (c *Client) setHeaders(req *http.Request, apiKey string) {
 	req.Header.Set("Content-Type", "application/json")
-	if c.APIKey != "" {
-		req.Header.Set("x-api-key", c.APIKey)
+	if apiKey != "" {
+		req.Header.Set("x-api-key", apiKey)
+	}
+	userAgent := c.UserAgent
+	if userAgent == "" {
+		userAgent = DefaultUserAgent()
 	}
-	req.Header.Set("User-Agent", "valyu-go/1.0.0")
+	req.Header.Set("User-Agent", userAgent)
 }
 
 func (c *Client) handleError(resp *http.Response) error {
+	return ErrorFromResponse(resp)
+}
+
+var requestIDHeaders = []string{"X-Request-Id", "Request-Id", "X-Amzn-RequestId", "X-Amz-Request-Id"}
+
+// ErrorFromResponse builds a *common.APIError from an error response. It
+// consumes the response body but does not close it.
+func ErrorFromResponse(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+
+	apiErr := &common.APIError{
+		StatusCode: resp.StatusCode,
+		Body:       body,
+	}
+
 	var errResp struct {
-		Error string `json:"error"`
+		Error   json.RawMessage `json:"error"`
+		Message string          `json:"message"`
+		Code    string          `json:"code"`
 	}
-	_ = json.NewDecoder(resp.Body).Decode(&errResp)
+	if json.Unmarshal(body, &errResp) == nil {
+		apiErr.Message = errResp.Message
+		apiErr.Code = errResp.Code
 
-	return &APIError{
-		StatusCode: resp.StatusCode,
-		Message:    errResp.Error,
+		var msg string
+		var nested struct {
+			Message string `json:"message"`
+			Code    string `json:"code"`
+			Type    string `json:"type"`
+		}
+		if json.Unmarshal(errResp.Error, &msg) == nil && msg != "" {
+			apiErr.Message = msg
+		} else if json.Unmarshal(errResp.Error, &nested) == nil {
+			if nested.Message != "" {
+				apiErr.Message = nested.Message
+			}
+			if apiErr.Code == "" {
+				apiErr.Code = nested.Code
+			}
+			if apiErr.Code == "" {
+				apiErr.Code = nested.Type
+			}
+		}
 	}
+
+	apiErr.RequestID = requestID(resp.Header)
+	if d, ok := retryAfter(resp.Header, time.Now()); ok {
+		apiErr.RetryAfter = d
+	}
+
+	return apiErr
 }
@@ -0,0 +1,230 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Veri5ied/valyu-go/valyu/common"
+)
+
+func TestStreamErrorStatus(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		body       string
+		wantErr    error
+		wantStatus int
+	}{
+		{"ok", http.StatusOK, "data: {}\n\n", nil, http.StatusOK},
+		{"unauthorized", http.StatusUnauthorized, `{"error":"bad key"}`, common.ErrUnauthorized, http.StatusUnauthorized},
+		{"server error", http.StatusInternalServerError, `{"error":"boom"}`, common.ErrServer, http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				io.WriteString(w, tt.body)
+			}))
+			defer srv.Close()
+
+			var seenErr error
+			var seenStatus int
+			c := New(srv.URL, "key", srv.Client())
+			c.Middleware = []Middleware{func(next Handler) Handler {
+				return func(ctx context.Context, req *Request) (*Response, error) {
+					resp, err := next(ctx, req)
+					seenErr = err
+					if resp != nil {
+						seenStatus = resp.HTTPResponse.StatusCode
+					}
+					return resp, err
+				}
+			}}
+
+			resp, err := c.PostStream(context.Background(), "/answer", map[string]string{"query": "q"})
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatal(err)
+				}
+				resp.Body.Close()
+			} else {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				var apiErr *common.APIError
+				if !errors.As(err, &apiErr) || apiErr.StatusCode != tt.status {
+					t.Errorf("err = %#v, want *APIError with status %d", err, tt.status)
+				}
+			}
+			if !errors.Is(seenErr, tt.wantErr) && seenErr != tt.wantErr {
+				t.Errorf("middleware saw err %v, want %v", seenErr, tt.wantErr)
+			}
+			if seenStatus != tt.wantStatus {
+				t.Errorf("middleware saw status %d, want %d", seenStatus, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestErrorFromResponse(t *testing.T) {
+	tests := []struct {
+		name          string
+		status        int
+		header        http.Header
+		body          string
+		wantKind      error
+		wantCode      string
+		wantMessage   string
+		wantRequestID string
+		wantRetry     time.Duration
+	}{
+		{
+			name:        "string error",
+			status:      400,
+			body:        `{"error":"query is required"}`,
+			wantKind:    common.ErrValidation,
+			wantMessage: "query is required",
+		},
+		{
+			name:        "nested error",
+			status:      402,
+			body:        `{"error":{"message":"top up","code":"insufficient_credits","type":"billing"}}`,
+			wantKind:    common.ErrInsufficientCredits,
+			wantCode:    "insufficient_credits",
+			wantMessage: "top up",
+		},
+		{
+			name:        "nested type as code",
+			status:      401,
+			body:        `{"error":{"message":"bad key","type":"auth_error"}}`,
+			wantKind:    common.ErrUnauthorized,
+			wantCode:    "auth_error",
+			wantMessage: "bad key",
+		},
+		{
+			name:        "top-level code wins",
+			status:      403,
+			body:        `{"code":"credit_exhausted","error":{"message":"no credits","code":"forbidden"}}`,
+			wantKind:    common.ErrInsufficientCredits,
+			wantCode:    "credit_exhausted",
+			wantMessage: "no credits",
+		},
+		{
+			name:        "message field",
+			status:      404,
+			body:        `{"message":"no such task"}`,
+			wantKind:    common.ErrNotFound,
+			wantMessage: "no such task",
+		},
+		{
+			name:     "not JSON",
+			status:   502,
+			body:     `<html>Bad Gateway</html>`,
+			wantKind: common.ErrServer,
+		},
+		{
+			name:          "request id and retry-after",
+			status:        429,
+			header:        http.Header{"X-Request-Id": {"req-1"}, "Retry-After": {"7"}},
+			body:          `{"error":"slow down"}`,
+			wantKind:      common.ErrRateLimited,
+			wantMessage:   "slow down",
+			wantRequestID: "req-1",
+			wantRetry:     7 * time.Second,
+		},
+		{
+			name:          "alternate request id and reset headers",
+			status:        503,
+			header:        http.Header{"X-Amzn-Requestid": {"amzn-1"}, "X-Ratelimit-Reset": {"3"}},
+			wantKind:      common.ErrServer,
+			wantRequestID: "amzn-1",
+			wantRetry:     3 * time.Second,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := tt.header
+			if header == nil {
+				header = http.Header{}
+			}
+			resp := &http.Response{StatusCode: tt.status, Header: header, Body: io.NopCloser(strings.NewReader(tt.body))}
+
+			err := ErrorFromResponse(resp)
+			apiErr, ok := common.AsAPIError(err)
+			if !ok {
+				t.Fatalf("ErrorFromResponse = %#v, want *common.APIError", err)
+			}
+			if !errors.Is(err, tt.wantKind) {
+				t.Errorf("err = %v, want it to match %v", err, tt.wantKind)
+			}
+			if apiErr.StatusCode != tt.status || apiErr.Code != tt.wantCode || apiErr.Message != tt.wantMessage {
+				t.Errorf("status, code, message = %d, %q, %q; want %d, %q, %q",
+					apiErr.StatusCode, apiErr.Code, apiErr.Message, tt.status, tt.wantCode, tt.wantMessage)
+			}
+			if apiErr.RequestID != tt.wantRequestID || apiErr.RetryAfter != tt.wantRetry {
+				t.Errorf("request id, retry after = %q, %v; want %q, %v", apiErr.RequestID, apiErr.RetryAfter, tt.wantRequestID, tt.wantRetry)
+			}
+			if string(apiErr.Body) != tt.body {
+				t.Errorf("Body = %q, want %q", apiErr.Body, tt.body)
+			}
+		})
+	}
+}
+
+func TestCheckSuccess(t *testing.T) {
+	type result struct {
+		Success bool   `json:"success"`
+		Error   string `json:"error"`
+		Results []int  `json:"results"`
+	}
+	tests := []struct {
+		name        string
+		body        string
+		check       bool
+		wantErr     bool
+		wantMessage string
+		wantTxID    string
+	}{
+		{"success", `{"success":true,"results":[1]}`, true, false, "", ""},
+		{"no success field", `{"results":[1]}`, true, false, "", ""},
+		{"failure", `{"success":false,"error":"no results","tx_id":"tx-1","results":[1]}`, true, true, "no results", "tx-1"},
+		{"failure with message", `{"success":false,"message":"quota exceeded"}`, true, true, "quota exceeded", ""},
+		{"check disabled", `{"success":false,"error":"no results","results":[1]}`, false, false, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				io.WriteString(w, tt.body)
+			}))
+			defer srv.Close()
+
+			c := New(srv.URL, "key", srv.Client())
+			c.CheckSuccess = tt.check
+			var got result
+			err := c.Post(context.Background(), "/search", map[string]string{"query": "q"}, &got)
+
+			if !tt.wantErr {
+				if err != nil {
+					t.Fatalf("Post = %v, want nil", err)
+				}
+				return
+			}
+			respErr, ok := common.AsResponseError(err)
+			if !ok {
+				t.Fatalf("Post = %#v, want *common.ResponseError", err)
+			}
+			if respErr.Message != tt.wantMessage || respErr.TxID != tt.wantTxID {
+				t.Errorf("ResponseError = %+v, want message %q, tx id %q", respErr, tt.wantMessage, tt.wantTxID)
+			}
+			if respErr.Response != &got {
+				t.Errorf("ResponseError.Response = %#v, want the decoded result", respErr.Response)
+			}
+		})
+	}
+}
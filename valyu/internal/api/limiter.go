@@ -0,0 +1,172 @@
+package api
+
+import (
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Limit throttles requests with a token bucket of RequestsPerSecond refilled
+// up to Burst tokens, and caps the number of requests in flight. Zero fields
+// disable the corresponding limit.
+type Limit struct {
+	RequestsPerSecond float64
+	Burst             int
+	MaxInFlight       int
+}
+
+// Limiter applies a client-wide Limit plus optional per endpoint group limits.
+// A group is the first path segment of the endpoint, e.g. "search" or
+// "deepresearch". It is safe for concurrent use.
+type Limiter struct {
+	global *limit
+	groups map[string]*limit
+}
+
+func NewLimiter(global Limit, groups map[string]Limit) *Limiter {
+	l := &Limiter{
+		global: newLimit(global),
+		groups: make(map[string]*limit, len(groups)),
+	}
+	for name, g := range groups {
+		l.groups[strings.Trim(name, "/")] = newLimit(g)
+	}
+	return l
+}
+
+// EndpointGroup returns the group a request path belongs to.
+func EndpointGroup(path string) string {
+	path = strings.TrimPrefix(path, "/")
+	if i := strings.IndexAny(path, "/?"); i >= 0 {
+		path = path[:i]
+	}
+	return path
+}
+
+// Acquire blocks until the request at path may be sent, or ctx is done. The
+// returned release func must be called once the request has completed.
+func (l *Limiter) Acquire(ctx context.Context, path string) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+
+	releaseGlobal, err := l.global.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	group := l.groups[EndpointGroup(path)]
+	releaseGroup, err := group.acquire(ctx)
+	if err != nil {
+		releaseGlobal()
+		return nil, err
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			releaseGroup()
+			releaseGlobal()
+		})
+	}, nil
+}
+
+type limit struct {
+	bucket *tokenBucket
+	sem    chan struct{}
+}
+
+func newLimit(cfg Limit) *limit {
+	l := &limit{}
+	if cfg.RequestsPerSecond > 0 {
+		burst := cfg.Burst
+		if burst < 1 {
+			burst = 1
+		}
+		l.bucket = &tokenBucket{
+			rate:   cfg.RequestsPerSecond,
+			burst:  float64(burst),
+			tokens: float64(burst),
+			last:   time.Now(),
+		}
+	}
+	if cfg.MaxInFlight > 0 {
+		l.sem = make(chan struct{}, cfg.MaxInFlight)
+	}
+	return l
+}
+
+func (l *limit) acquire(ctx context.Context) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+	if l.sem != nil {
+		select {
+		case l.sem <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	release := func() {
+		if l.sem != nil {
+			<-l.sem
+		}
+	}
+	if l.bucket != nil {
+		if err := l.bucket.wait(ctx); err != nil {
+			release()
+			return nil, err
+		}
+	}
+	return release, nil
+}
+
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// wait reserves a token, sleeping until it is available. The reservation is
+// returned to the bucket if ctx ends first.
+func (b *tokenBucket) wait(ctx context.Context) error {
+	b.mu.Lock()
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+	b.tokens--
+	var delay time.Duration
+	if b.tokens < 0 {
+		delay = time.Duration(-b.tokens / b.rate * float64(time.Second))
+	}
+	b.mu.Unlock()
+
+	if delay == 0 {
+		return nil
+	}
+	if err := sleep(ctx, delay); err != nil {
+		b.mu.Lock()
+		b.tokens++
+		b.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// releaseBody calls release when the response body is closed.
+type releaseBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *releaseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.release()
+	return err
+}
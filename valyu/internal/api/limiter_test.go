@@ -0,0 +1,181 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestEndpointGroup(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/search", "search"},
+		{"search", "search"},
+		{"/deepresearch/tasks/abc/status", "deepresearch"},
+		{"/datasources?category=research", "datasources"},
+		{"/", ""},
+	}
+	for _, tt := range tests {
+		if got := EndpointGroup(tt.path); got != tt.want {
+			t.Errorf("EndpointGroup(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestTokenBucket(t *testing.T) {
+	l := NewLimiter(Limit{RequestsPerSecond: 20, Burst: 2}, nil)
+	ctx := context.Background()
+
+	start := time.Now()
+	for i := 0; i < 2; i++ {
+		release, err := l.Acquire(ctx, "/search")
+		if err != nil {
+			t.Fatal(err)
+		}
+		release()
+	}
+	if elapsed := time.Since(start); elapsed > 25*time.Millisecond {
+		t.Errorf("burst of 2 took %v, want no wait", elapsed)
+	}
+
+	start = time.Now()
+	release, err := l.Acquire(ctx, "/search")
+	if err != nil {
+		t.Fatal(err)
+	}
+	release()
+	if elapsed := time.Since(start); elapsed < 25*time.Millisecond {
+		t.Errorf("third request waited %v, want about 50ms for the next token", elapsed)
+	}
+}
+
+func TestTokenBucketCancel(t *testing.T) {
+	l := NewLimiter(Limit{RequestsPerSecond: 1}, nil)
+	release, err := l.Acquire(context.Background(), "/search")
+	if err != nil {
+		t.Fatal(err)
+	}
+	release()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+	start := time.Now()
+	if _, err := l.Acquire(ctx, "/search"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Acquire = %v, want context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Acquire returned after %v, want the 1s wait cut short", elapsed)
+	}
+
+	b := l.global.bucket
+	b.mu.Lock()
+	tokens := b.tokens
+	b.mu.Unlock()
+	if tokens < -0.5 {
+		t.Errorf("tokens = %v after cancelling, want the reservation returned", tokens)
+	}
+}
+
+func TestLimiterMaxInFlight(t *testing.T) {
+	l := NewLimiter(Limit{MaxInFlight: 1}, nil)
+	release, err := l.Acquire(context.Background(), "/search")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := l.Acquire(ctx, "/answer"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("second Acquire = %v, want it to block until the deadline", err)
+	}
+
+	release()
+	release() // a second call must not free another slot
+	if n := len(l.global.sem); n != 0 {
+		t.Fatalf("%d slots held after release, want 0", n)
+	}
+
+	acquired := make(chan func())
+	go func() {
+		r, err := l.Acquire(context.Background(), "/answer")
+		if err != nil {
+			t.Error(err)
+		}
+		acquired <- r
+	}()
+	select {
+	case r := <-acquired:
+		r()
+	case <-time.After(time.Second):
+		t.Fatal("Acquire still blocked after release")
+	}
+}
+
+func TestLimiterGroups(t *testing.T) {
+	l := NewLimiter(Limit{MaxInFlight: 3}, map[string]Limit{"/search/": {MaxInFlight: 1}})
+	release, err := l.Acquire(context.Background(), "/search")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer release()
+
+	other, err := l.Acquire(context.Background(), "/answer")
+	if err != nil {
+		t.Fatalf("Acquire in another group = %v", err)
+	}
+	other()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := l.Acquire(ctx, "/search?page=2"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Acquire in the full group = %v, want it to block until the deadline", err)
+	}
+	if n := len(l.global.sem); n != 1 {
+		t.Errorf("%d global slots held, want only the first request's", n)
+	}
+}
+
+func TestNilLimiter(t *testing.T) {
+	var l *Limiter
+	release, err := l.Acquire(context.Background(), "/search")
+	if err != nil {
+		t.Fatal(err)
+	}
+	release()
+}
+
+func TestStreamReleasesSlotOnClose(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"success":true}`)
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL, "key", srv.Client())
+	c.Limiter = NewLimiter(Limit{MaxInFlight: 1}, nil)
+
+	resp, err := c.PostStream(context.Background(), "/answer", map[string]string{"query": "q"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.Copy(io.Discard, resp.Body)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := c.Post(ctx, "/search", map[string]string{"query": "q"}, nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Post while the stream is open = %v, want it to wait for the slot", err)
+	}
+
+	resp.Body.Close()
+	if err := c.Post(context.Background(), "/search", map[string]string{"query": "q"}, nil); err != nil {
+		t.Fatalf("Post after closing the stream = %v", err)
+	}
+	if n := len(c.Limiter.global.sem); n != 0 {
+		t.Errorf("%d slots held after both requests finished, want 0", n)
+	}
+}
@@ -0,0 +1,243 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const redacted = "[REDACTED]"
+
+// sensitiveHeaderWords mark a header as secret when its name contains one
+// of them, which also covers credentials added with option.WithHeader such
+// as X-Custom-Token.
+var sensitiveHeaderWords = []string{"auth", "token", "key", "secret", "password", "cookie", "session", "signature"}
+
+// publicHeaders contain one of sensitiveHeaderWords but carry no secret.
+var publicHeaders = map[string]bool{
+	"idempotency-key": true,
+}
+
+var sensitiveKeys = map[string]bool{
+	"token":          true,
+	"api_key":        true,
+	"apikey":         true,
+	"x-api-key":      true,
+	"authorization":  true,
+	"password":       true,
+	"secret":         true,
+	"webhook_secret": true,
+	"client_secret":  true,
+	"access_token":   true,
+	"refresh_token":  true,
+}
+
+func (c *Client) logEnabled(ctx context.Context, level slog.Level) bool {
+	return c.Logger != nil && c.Logger.Enabled(ctx, level)
+}
+
+func (c *Client) logRequest(ctx context.Context, method, path string, status, attempts int, start time.Time, txID string, err error) {
+	level := c.LogLevel
+	if err != nil || status >= 400 {
+		level = slog.LevelWarn
+	}
+	if !c.logEnabled(ctx, level) {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("method", method),
+		slog.String("path", path),
+		slog.Duration("latency", time.Since(start)),
+		slog.Int("attempts", attempts),
+	}
+	if status != 0 {
+		attrs = append(attrs, slog.Int("status", status))
+	}
+	if txID != "" {
+		attrs = append(attrs, slog.String("tx_id", txID))
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+	c.Logger.LogAttrs(ctx, level, "valyu request", attrs...)
+}
+
+func (c *Client) logRetry(ctx context.Context, method, path string, attempt int, delay time.Duration, status int, err error) {
+	if !c.logEnabled(ctx, slog.LevelWarn) {
+		return
+	}
+	attrs := []slog.Attr{
+		slog.String("method", method),
+		slog.String("path", path),
+		slog.Int("attempt", attempt),
+		slog.Duration("delay", delay),
+	}
+	if status != 0 {
+		attrs = append(attrs, slog.Int("status", status))
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+	c.Logger.LogAttrs(ctx, slog.LevelWarn, "valyu retrying request", attrs...)
+}
+
+func (c *Client) dumpRequest(ctx context.Context, req *http.Request, body []byte) {
+	if !c.LogBodies || !c.logEnabled(ctx, slog.LevelDebug) {
+		return
+	}
+	c.Logger.LogAttrs(ctx, slog.LevelDebug, "valyu request body",
+		slog.String("method", req.Method),
+		slog.String("path", req.URL.Path),
+		slog.Any("headers", redactHeader(req.Header)),
+		slog.String("body", RedactJSON(body)),
+	)
+}
+
+func (c *Client) dumpResponse(ctx context.Context, path string, resp *http.Response, body []byte) {
+	if !c.LogBodies || !c.logEnabled(ctx, slog.LevelDebug) {
+		return
+	}
+	c.Logger.LogAttrs(ctx, slog.LevelDebug, "valyu response body",
+		slog.String("path", path),
+		slog.Int("status", resp.StatusCode),
+		slog.Any("headers", redactHeader(resp.Header)),
+		slog.String("body", RedactJSON(body)),
+	)
+}
+
+// dumpStream logs each line of an event stream as the caller reads it.
+func (c *Client) dumpStream(ctx context.Context, path string, resp *http.Response) {
+	if !c.LogBodies || !c.logEnabled(ctx, slog.LevelDebug) {
+		return
+	}
+	c.Logger.LogAttrs(ctx, slog.LevelDebug, "valyu response stream",
+		slog.String("path", path),
+		slog.Int("status", resp.StatusCode),
+		slog.Any("headers", redactHeader(resp.Header)),
+	)
+	resp.Body = &streamDump{c: c, ctx: ctx, path: path, body: resp.Body}
+}
+
+type streamDump struct {
+	c    *Client
+	ctx  context.Context
+	path string
+	body io.ReadCloser
+	line []byte
+}
+
+func (d *streamDump) Read(p []byte) (int, error) {
+	n, err := d.body.Read(p)
+	buf := p[:n]
+	for len(buf) > 0 {
+		i := bytes.IndexByte(buf, '\n')
+		if i < 0 {
+			d.line = append(d.line, buf...)
+			break
+		}
+		d.line = append(d.line, buf[:i]...)
+		d.log()
+		buf = buf[i+1:]
+	}
+	return n, err
+}
+
+func (d *streamDump) Close() error {
+	d.log()
+	return d.body.Close()
+}
+
+func (d *streamDump) log() {
+	line := strings.TrimRight(string(d.line), "\r")
+	d.line = d.line[:0]
+	if line == "" {
+		return
+	}
+	if data, ok := strings.CutPrefix(line, "data:"); ok {
+		line = "data: " + RedactJSON([]byte(strings.TrimSpace(data)))
+	}
+	d.c.Logger.LogAttrs(d.ctx, slog.LevelDebug, "valyu stream event",
+		slog.String("path", d.path),
+		slog.String("line", line),
+	)
+}
+
+func redactHeader(h http.Header) map[string]string {
+	out := make(map[string]string, len(h))
+	for k, vs := range h {
+		v := strings.Join(vs, ", ")
+		if sensitiveHeader(k) {
+			v = redacted
+		}
+		out[k] = v
+	}
+	return out
+}
+
+func sensitiveHeader(name string) bool {
+	name = strings.ToLower(name)
+	if publicHeaders[name] {
+		return false
+	}
+	for _, w := range sensitiveHeaderWords {
+		if strings.Contains(name, w) {
+			return true
+		}
+	}
+	return false
+}
+
+// RedactJSON returns data with secret values (API keys, MCP auth tokens and
+// headers, webhook secrets) replaced. Non-JSON input is returned unchanged.
+func RedactJSON(data []byte) string {
+	if len(data) == 0 {
+		return ""
+	}
+	var v interface{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return string(data)
+	}
+	out, err := json.Marshal(redactValue(v, ""))
+	if err != nil {
+		return string(data)
+	}
+	return string(out)
+}
+
+func redactValue(v interface{}, parent string) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, child := range t {
+			key := strings.ToLower(k)
+			switch {
+			case sensitiveKeys[key]:
+				if child != nil && child != "" {
+					t[k] = redacted
+				}
+			case key == "headers" && parent == "auth":
+				if headers, ok := child.(map[string]interface{}); ok {
+					for hk := range headers {
+						headers[hk] = redacted
+					}
+				}
+			default:
+				t[k] = redactValue(child, key)
+			}
+		}
+		return t
+	case []interface{}:
+		for i, child := range t {
+			t[i] = redactValue(child, parent)
+		}
+		return t
+	}
+	return v
+}
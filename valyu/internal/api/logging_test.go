@@ -0,0 +1,126 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRedactJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"empty", ``, ``},
+		{"not JSON", `data: hello`, `data: hello`},
+		{"no secrets", `{"query":"q","max_price":25}`, `{"max_price":25,"query":"q"}`},
+		{"large numbers kept", `{"n":12345678901234567890}`, `{"n":12345678901234567890}`},
+		{"api key", `{"api_key":"k","x-api-key":"k"}`, `{"api_key":"[REDACTED]","x-api-key":"[REDACTED]"}`},
+		{"empty secret kept", `{"token":""}`, `{"token":""}`},
+		{"webhook secret", `{"query":"q","webhook_secret":"whsec"}`, `{"query":"q","webhook_secret":"[REDACTED]"}`},
+		{
+			"mcp auth token",
+			`{"mcp_servers":[{"url":"https://mcp.example.com","auth":{"type":"bearer","token":"t0k"}}]}`,
+			`{"mcp_servers":[{"auth":{"token":"[REDACTED]","type":"bearer"},"url":"https://mcp.example.com"}]}`,
+		},
+		{
+			"mcp auth headers",
+			`{"mcp_servers":[{"auth":{"type":"headers","headers":{"X-Org":"acme","X-Key":"k"}}}]}`,
+			`{"mcp_servers":[{"auth":{"headers":{"X-Key":"[REDACTED]","X-Org":"[REDACTED]"},"type":"headers"}}]}`,
+		},
+		{"headers outside auth kept", `{"headers":{"X-Org":"acme"}}`, `{"headers":{"X-Org":"acme"}}`},
+		{"case insensitive", `{"Authorization":"Bearer x","Password":"p"}`, `{"Authorization":"[REDACTED]","Password":"[REDACTED]"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RedactJSON([]byte(tt.in)); got != tt.want {
+				t.Errorf("RedactJSON(%s) = %s, want %s", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSensitiveHeader(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"X-Api-Key", true},
+		{"Authorization", true},
+		{"Proxy-Authorization", true},
+		{"Cookie", true},
+		{"Set-Cookie", true},
+		{"X-Custom-Token", true},
+		{"X-Client-Secret", true},
+		{"X-Session-Id", true},
+		{"X-Webhook-Signature", true},
+		{"Idempotency-Key", false},
+		{"Content-Type", false},
+		{"User-Agent", false},
+		{"X-Request-Id", false},
+	}
+	for _, tt := range tests {
+		if got := sensitiveHeader(tt.name); got != tt.want {
+			t.Errorf("sensitiveHeader(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestBodyDumpRedacts(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/answer" {
+			io.WriteString(w, "data: {\"token\":\"stream-secret\",\"text\":\"visible\"}\n\ndata: [DONE]\n\n")
+			return
+		}
+		w.Header().Set("Set-Cookie", "session=cookie-secret")
+		io.WriteString(w, `{"success":true,"access_token":"response-secret"}`)
+	}))
+	defer srv.Close()
+
+	var logs bytes.Buffer
+	c := New(srv.URL, "api-key-secret", srv.Client())
+	c.Logger = slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	c.LogBodies = true
+	customHeader := func(r *Request) { r.Header.Set("X-Custom-Token", "header-secret") }
+
+	body := map[string]interface{}{
+		"query":          "q",
+		"webhook_secret": "webhook-secret",
+		"mcp_servers": []map[string]interface{}{{
+			"url":  "https://mcp.example.com",
+			"auth": map[string]interface{}{"type": "headers", "headers": map[string]string{"X-Org": "mcp-header-secret"}, "token": "mcp-token-secret"},
+		}},
+	}
+	var result json.RawMessage
+	if err := c.Post(context.Background(), "/deepresearch/tasks", body, &result, customHeader); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := c.PostStream(context.Background(), "/answer", map[string]string{"query": "q"}, customHeader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	out := logs.String()
+	for _, secret := range []string{
+		"api-key-secret", "header-secret", "webhook-secret", "mcp-header-secret",
+		"mcp-token-secret", "cookie-secret", "response-secret", "stream-secret",
+	} {
+		if strings.Contains(out, secret) {
+			t.Errorf("log contains %q:\n%s", secret, out)
+		}
+	}
+	for _, visible := range []string{"mcp.example.com", "visible", "[DONE]"} {
+		if !strings.Contains(out, visible) {
+			t.Errorf("log is missing %q:\n%s", visible, out)
+		}
+	}
+}
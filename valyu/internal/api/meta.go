@@ -0,0 +1,88 @@
+package api
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Veri5ied/valyu-go/valyu/common"
+)
+
+func fillMeta(meta *common.ResponseMeta, resp *http.Response, attempts int, start time.Time) {
+	if meta == nil {
+		return
+	}
+	*meta = common.ResponseMeta{
+		Attempts: attempts,
+		Duration: time.Since(start),
+	}
+	if resp == nil {
+		return
+	}
+	meta.StatusCode = resp.StatusCode
+	meta.Header = resp.Header.Clone()
+	meta.RequestID = requestID(resp.Header)
+	meta.RateLimit = parseRateLimit(resp.Header, time.Now())
+	meta.ServerTiming = parseServerTiming(resp.Header.Values("Server-Timing"))
+}
+
+func requestID(h http.Header) string {
+	for _, name := range requestIDHeaders {
+		if v := h.Get(name); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func parseRateLimit(h http.Header, now time.Time) common.RateLimit {
+	rl := common.RateLimit{Limit: -1, Remaining: -1}
+	for _, prefix := range []string{"X-RateLimit-", "RateLimit-"} {
+		if v, err := strconv.Atoi(h.Get(prefix + "Limit")); err == nil && rl.Limit < 0 {
+			rl.Limit = v
+		}
+		if v, err := strconv.Atoi(h.Get(prefix + "Remaining")); err == nil && rl.Remaining < 0 {
+			rl.Remaining = v
+		}
+		if v, err := strconv.ParseFloat(h.Get(prefix+"Reset"), 64); err == nil && rl.Reset.IsZero() {
+			if v > 1e9 {
+				sec, frac := math.Modf(v)
+				rl.Reset = time.Unix(int64(sec), int64(frac*1e9))
+			} else {
+				rl.Reset = now.Add(time.Duration(v * float64(time.Second)))
+			}
+		}
+	}
+	return rl
+}
+
+// parseServerTiming parses values such as `db;dur=53.2;desc="Query", cache`.
+func parseServerTiming(values []string) []common.ServerTiming {
+	var out []common.ServerTiming
+	for _, value := range values {
+		for _, metric := range strings.Split(value, ",") {
+			parts := strings.Split(metric, ";")
+			name := strings.TrimSpace(parts[0])
+			if name == "" {
+				continue
+			}
+			st := common.ServerTiming{Name: name}
+			for _, param := range parts[1:] {
+				k, v, _ := strings.Cut(strings.TrimSpace(param), "=")
+				v = strings.Trim(v, `"`)
+				switch strings.ToLower(k) {
+				case "dur":
+					if ms, err := strconv.ParseFloat(v, 64); err == nil {
+						st.Duration = time.Duration(ms * float64(time.Millisecond))
+					}
+				case "desc":
+					st.Description = v
+				}
+			}
+			out = append(out, st)
+		}
+	}
+	return out
+}
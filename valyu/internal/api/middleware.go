@@ -0,0 +1,59 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/Veri5ied/valyu-go/valyu/common"
+)
+
+// Request describes a single SDK call as seen by middleware. It starts from
+// the client defaults with any per-call RequestOptions applied. Header values
+// are applied on top of the SDK defaults, so middleware can add or replace
+// headers such as x-api-key.
+type Request struct {
+	Method string
+	Path   string
+	Body   interface{}
+	Header http.Header
+	Stream bool
+
+	BaseURL        string
+	APIKey         string
+	Timeout        time.Duration
+	IdempotencyKey string
+
+	// ResponseMeta, if set, is filled in once the response arrives.
+	ResponseMeta *common.ResponseMeta
+}
+
+// RequestOption adjusts a single call.
+type RequestOption func(*Request)
+
+// Response is what a Handler returns. For streaming calls Result is nil and
+// HTTPResponse.Body is the open event stream; for other calls the body has
+// already been consumed and Result holds the decoded value.
+type Response struct {
+	HTTPResponse *http.Response
+	Result       interface{}
+}
+
+type Handler func(ctx context.Context, req *Request) (*Response, error)
+
+// Middleware wraps a Handler. Middlewares run in the order they were
+// registered, the first being the outermost.
+type Middleware func(next Handler) Handler
+
+func (c *Client) handle(ctx context.Context, req *Request, result interface{}) (*Response, error) {
+	var h Handler = func(ctx context.Context, req *Request) (*Response, error) {
+		if req.Stream {
+			return c.stream(ctx, req)
+		}
+		return c.roundTrip(ctx, req, result)
+	}
+	for i := len(c.Middleware) - 1; i >= 0; i-- {
+		h = c.Middleware[i](h)
+	}
+	return h(ctx, req)
+}
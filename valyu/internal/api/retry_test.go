@@ -0,0 +1,247 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/Veri5ied/valyu-go/valyu/common"
+)
+
+func TestRetryPolicyNormalize(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy RetryPolicy
+		want   RetryPolicy
+	}{
+		{"zero is one attempt", RetryPolicy{}, RetryPolicy{MaxAttempts: 1}},
+		{"one attempt drops the rest", RetryPolicy{MaxAttempts: 1, BaseDelay: time.Second}, RetryPolicy{MaxAttempts: 1}},
+		{
+			"defaults fill zero fields",
+			RetryPolicy{MaxAttempts: 4},
+			RetryPolicy{MaxAttempts: 4, BaseDelay: DefaultRetryPolicy.BaseDelay, MaxDelay: DefaultRetryPolicy.MaxDelay},
+		},
+		{
+			"max delay at least base delay",
+			RetryPolicy{MaxAttempts: 2, BaseDelay: time.Minute, MaxDelay: time.Second},
+			RetryPolicy{MaxAttempts: 2, BaseDelay: time.Minute, MaxDelay: time.Minute},
+		},
+		{
+			"jitter clamped",
+			RetryPolicy{MaxAttempts: 2, BaseDelay: time.Second, MaxDelay: time.Second, Jitter: 3},
+			RetryPolicy{MaxAttempts: 2, BaseDelay: time.Second, MaxDelay: time.Second, Jitter: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.policy.normalize()
+			if got.MaxAttempts != tt.want.MaxAttempts || got.BaseDelay != tt.want.BaseDelay ||
+				got.MaxDelay != tt.want.MaxDelay || got.Jitter != tt.want.Jitter {
+				t.Errorf("normalize() = %+v, want %+v", got, tt.want)
+			}
+			if got.MaxAttempts > 1 && (got.RetryableStatusCodes == nil || got.RetryableError == nil) {
+				t.Errorf("normalize() left retry predicates unset: %+v", got)
+			}
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 10, BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	tests := []struct {
+		retry int
+		want  time.Duration
+	}{
+		{1, 100 * time.Millisecond},
+		{2, 200 * time.Millisecond},
+		{3, 400 * time.Millisecond},
+		{4, 800 * time.Millisecond},
+		{5, time.Second},
+		{9, time.Second},
+	}
+	for _, tt := range tests {
+		if got := p.backoff(tt.retry); got != tt.want {
+			t.Errorf("backoff(%d) = %v, want %v", tt.retry, got, tt.want)
+		}
+	}
+
+	p.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		if got := p.backoff(2); got < 100*time.Millisecond || got > 300*time.Millisecond {
+			t.Fatalf("backoff(2) with jitter 0.5 = %v, want within [100ms, 300ms]", got)
+		}
+		if got := p.backoff(5); got > p.MaxDelay {
+			t.Fatalf("backoff(5) with jitter = %v, over MaxDelay", got)
+		}
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		name   string
+		header http.Header
+		want   time.Duration
+		wantOK bool
+	}{
+		{"none", http.Header{}, 0, false},
+		{"seconds", http.Header{"Retry-After": {"2"}}, 2 * time.Second, true},
+		{"fractional seconds", http.Header{"Retry-After": {"0.5"}}, 500 * time.Millisecond, true},
+		{"http date", http.Header{"Retry-After": {now.Add(3 * time.Second).Format(http.TimeFormat)}}, 3 * time.Second, true},
+		{"date in the past", http.Header{"Retry-After": {now.Add(-time.Minute).Format(http.TimeFormat)}}, 0, true},
+		{"garbage falls through", http.Header{"Retry-After": {"soon"}}, 0, false},
+		{"reset seconds", http.Header{"X-Ratelimit-Reset": {"7"}}, 7 * time.Second, true},
+		{"reset timestamp", http.Header{"Ratelimit-Reset": {fmt.Sprint(now.Add(4 * time.Second).Unix())}}, 4 * time.Second, true},
+		{"retry-after wins", http.Header{"Retry-After": {"1"}, "X-Ratelimit-Reset": {"9"}}, time.Second, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := retryAfter(tt.header, now)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("retryAfter = %v, %v; want %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestIsRetryableError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"timeout", &net.OpError{Op: "read", Err: timeoutError{}}, true},
+		{"reset", fmt.Errorf("read: %w", syscall.ECONNRESET), true},
+		{"refused", &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}, true},
+		{"truncated", io.ErrUnexpectedEOF, true},
+		{"canceled", context.Canceled, false},
+		{"other", errors.New("bad certificate"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRetryableError(tt.err); got != tt.want {
+				t.Errorf("IsRetryableError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestSendRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		statuses     []int
+		retryAfter   string
+		wantAttempts int
+		wantStatus   int
+	}{
+		{"succeeds first time", []int{200}, "", 1, 200},
+		{"retries server errors", []int{503, 502, 200}, "", 3, 200},
+		{"honours retry-after", []int{429, 200}, "0", 2, 200},
+		{"gives up after max attempts", []int{500, 500, 500, 200}, "", 3, 500},
+		{"does not retry client errors", []int{400, 200}, "", 1, 400},
+		{"retry-after over max delay", []int{429, 200}, "3600", 1, 429},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				mu     sync.Mutex
+				keys   []string
+				bodies []string
+			)
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				mu.Lock()
+				n := len(keys)
+				keys = append(keys, r.Header.Get("Idempotency-Key"))
+				bodies = append(bodies, string(body))
+				mu.Unlock()
+				if tt.retryAfter != "" {
+					w.Header().Set("Retry-After", tt.retryAfter)
+				}
+				w.WriteHeader(tt.statuses[n])
+				io.WriteString(w, `{"success":true}`)
+			}))
+			defer srv.Close()
+
+			c := New(srv.URL, "key", srv.Client())
+			c.Retry = RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond}
+			var result map[string]interface{}
+			start := time.Now()
+			err := c.Post(context.Background(), "/search", map[string]string{"query": "q"}, &result)
+
+			if tt.wantStatus == http.StatusOK {
+				if err != nil {
+					t.Fatalf("Post = %v", err)
+				}
+			} else if apiErr, ok := common.AsAPIError(err); !ok || apiErr.StatusCode != tt.wantStatus {
+				t.Fatalf("Post = %v, want an APIError with status %d", err, tt.wantStatus)
+			} else if tt.retryAfter == "3600" && apiErr.RetryAfter != time.Hour {
+				t.Errorf("RetryAfter = %v, want 1h", apiErr.RetryAfter)
+			}
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Errorf("Post took %v", elapsed)
+			}
+			if len(keys) != tt.wantAttempts {
+				t.Errorf("made %d attempts, want %d", len(keys), tt.wantAttempts)
+			}
+			for _, k := range keys {
+				if k == "" || k != keys[0] {
+					t.Errorf("Idempotency-Key across attempts = %q, want one non-empty key", keys)
+					break
+				}
+			}
+			for _, b := range bodies {
+				if b != `{"query":"q"}` {
+					t.Errorf("bodies across attempts = %q, want the request body each time", bodies)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestSendCancelDuringBackoff(t *testing.T) {
+	attempts := make(chan struct{}, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts <- struct{}{}
+		w.Header().Set("Retry-After", "5")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL, "key", srv.Client())
+	c.Retry = RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Minute}
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-attempts
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+
+	start := time.Now()
+	err := c.Post(ctx, "/search", map[string]string{"query": "q"}, nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Post = %v, want context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Post returned after %v, want the 5s backoff cut short", elapsed)
+	}
+	if n := len(attempts); n != 0 {
+		t.Errorf("made %d more attempts after cancelling", n)
+	}
+}
@@ -0,0 +1,52 @@
+package api
+
+import (
+	"runtime/debug"
+	"strings"
+	"sync"
+)
+
+const modulePath = "github.com/Veri5ied/valyu-go"
+
+var (
+	versionOnce sync.Once
+	version     string
+)
+
+// Version reports the version of the SDK module linked into the binary, or
+// "devel" when it is built from a local checkout.
+func Version() string {
+	versionOnce.Do(func() {
+		version = "devel"
+		info, ok := debug.ReadBuildInfo()
+		if !ok {
+			return
+		}
+		mod := &info.Main
+		if mod.Path != modulePath {
+			mod = nil
+			for _, dep := range info.Deps {
+				if dep.Path == modulePath {
+					mod = dep
+					break
+				}
+			}
+		}
+		if mod == nil {
+			return
+		}
+		v := mod.Version
+		if mod.Replace != nil && mod.Replace.Version != "" {
+			v = mod.Replace.Version
+		}
+		if v != "" && v != "(devel)" {
+			version = strings.TrimPrefix(v, "v")
+		}
+	})
+	return version
+}
+
+// DefaultUserAgent is sent unless the client is configured otherwise.
+func DefaultUserAgent() string {
+	return "valyu-go/" + Version()
+}
@@ -0,0 +1,136 @@
+// Package partialjson repairs a truncated JSON document, such as structured
+// output that is still streaming, into the closest valid document. Open
+// strings, arrays and objects are closed; incomplete keys, numbers and
+// literals are dropped.
+package partialjson
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Complete returns a valid JSON document for the prefix s. It reports false
+// if nothing in s can be salvaged yet, e.g. for an empty or blank prefix.
+func Complete(s string) (string, bool) {
+	end := len(s)
+	for end > 0 {
+		doc, last := closePrefix(s[:end])
+		if doc != "" && json.Valid([]byte(doc)) {
+			return doc, true
+		}
+		// Drop the last token and try again.
+		if last >= end {
+			last = end - 1
+		}
+		end = last
+	}
+	return "", false
+}
+
+// closePrefix closes whatever is open at the end of p. It also returns the
+// offset of the last token in p, which is where the caller cuts if the
+// result is still invalid.
+func closePrefix(p string) (string, int) {
+	var (
+		stack     []byte
+		inString  bool
+		escaped   bool
+		lastToken int
+		scalar    = -1
+	)
+	for i := 0; i < len(p); i++ {
+		c := p[i]
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+		if scalar >= 0 && !isScalarByte(c) {
+			scalar = -1
+		}
+		switch c {
+		case ' ', '\t', '\n', '\r':
+			continue
+		case '"':
+			inString = true
+			lastToken = i
+		case '{':
+			stack = append(stack, '}')
+			lastToken = i
+		case '[':
+			stack = append(stack, ']')
+			lastToken = i
+		case '}', ']':
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+			lastToken = i
+		case ',', ':':
+			lastToken = i
+		default:
+			if scalar < 0 {
+				scalar = i
+				lastToken = i
+			}
+		}
+	}
+
+	var b strings.Builder
+	switch {
+	case inString:
+		b.WriteString(trimEscape(p))
+		b.WriteByte('"')
+	case scalar >= 0 && !isLiteral(p[scalar:]):
+		// A number or literal at the very end may still be growing.
+		b.WriteString(p[:scalar])
+	default:
+		b.WriteString(p)
+	}
+
+	doc := strings.TrimRight(b.String(), " \t\r\n")
+	doc = strings.TrimSuffix(doc, ",")
+	b.Reset()
+	b.WriteString(doc)
+	for i := len(stack) - 1; i >= 0; i-- {
+		b.WriteByte(stack[i])
+	}
+	return b.String(), lastToken
+}
+
+func isScalarByte(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c == '-' || c == '+' || c == '.' || c == 'E'
+}
+
+func isLiteral(s string) bool {
+	return s == "true" || s == "false" || s == "null"
+}
+
+// trimEscape removes an escape sequence cut off at the end of an open string.
+func trimEscape(s string) string {
+	i := strings.LastIndexByte(s, '\\')
+	if i < 0 {
+		return s
+	}
+	// Count the backslashes ending at i; an even run is escaped literally.
+	n := 0
+	for j := i; j >= 0 && s[j] == '\\'; j-- {
+		n++
+	}
+	if n%2 == 0 {
+		return s
+	}
+	tail := s[i+1:]
+	switch {
+	case tail == "":
+		return s[:i]
+	case tail[0] == 'u' && len(tail) < 5:
+		return s[:i]
+	}
+	return s
+}
@@ -0,0 +1,93 @@
+package partialjson
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestComplete(t *testing.T) {
+	tests := []struct {
+		name   string
+		in     string
+		want   string
+		wantOK bool
+	}{
+		{"empty", "", "", false},
+		{"blank", "  \n", "", false},
+		{"complete", `{"a":1}`, `{"a":1}`, true},
+		{"open object", `{`, `{}`, true},
+		{"open array", `[1,2`, `[1]`, true},
+		{"trailing comma", `[1,2,`, `[1,2]`, true},
+		{"open string value", `{"title":"Attent`, `{"title":"Attent"}`, true},
+		{"open key", `{"title":"x","auth`, `{"title":"x"}`, true},
+		{"key without value", `{"title":"x","authors":`, `{"title":"x"}`, true},
+		{"growing number", `{"n":12`, `{}`, true},
+		{"finished number", `{"n":12,`, `{"n":12}`, true},
+		{"partial literal", `{"ok":tr`, `{}`, true},
+		{"whole literal", `{"ok":true`, `{"ok":true}`, true},
+		{"nested", `{"a":[{"b":"c"},{"d":[1,`, `{"a":[{"b":"c"},{"d":[1]}]}`, true},
+		{"cut escape", `["a\`, `["a"]`, true},
+		{"cut unicode escape", `["a\u00`, `["a"]`, true},
+		{"escaped backslash", `["a\\`, `["a\\"]`, true},
+		{"escaped quote", `["say \"hi`, `["say \"hi"]`, true},
+		{"brackets in string", `{"s":"[{`, `{"s":"[{"}`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Complete(tt.in)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("Complete(%q) = %q, %v; want %q, %v", tt.in, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+// TestCompletePrefixes checks that every prefix of a document completes to
+// valid JSON, and the whole document to itself.
+func TestCompletePrefixes(t *testing.T) {
+	docs := []string{
+		`{"title":"Attention Is All You Need","authors":["Vaswani","Shazeer"],"year":2017,"score":-1.5e3,"open":true,"doi":null}`,
+		`[{"q":"a \"quoted\" \\ path","u":"été"},[],{},[[1,2],[3]]]`,
+		` { "spaced" : [ 1 , 2 ] } `,
+	}
+	for _, doc := range docs {
+		for i := 1; i <= len(doc); i++ {
+			got, ok := Complete(doc[:i])
+			if !ok {
+				continue
+			}
+			if !json.Valid([]byte(got)) {
+				t.Fatalf("Complete(%q) = %q, not valid JSON", doc[:i], got)
+			}
+		}
+		if got, _ := Complete(doc); !json.Valid([]byte(got)) || compact(t, got) != compact(t, doc) {
+			t.Errorf("Complete(whole doc) = %q, want %q", got, doc)
+		}
+	}
+}
+
+func compact(t *testing.T, s string) string {
+	t.Helper()
+	var v interface{}
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		t.Fatal(err)
+	}
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+func FuzzComplete(f *testing.F) {
+	f.Add(`{"a":[1,"b",{"c":null}]}`)
+	f.Add(`["x\u12`)
+	f.Add(`{"k":tru`)
+	f.Fuzz(func(t *testing.T, s string) {
+		if !json.Valid([]byte(s)) {
+			return
+		}
+		for i := 1; i <= len(s); i++ {
+			if got, ok := Complete(s[:i]); ok && !json.Valid([]byte(got)) {
+				t.Fatalf("Complete(%q) = %q, not valid JSON", s[:i], got)
+			}
+		}
+	})
+}
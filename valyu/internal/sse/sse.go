@@ -0,0 +1,171 @@
+// Package sse decodes text/event-stream bodies as described by the WHATWG
+// HTML specification, section "Server-sent events". Lines may be of any
+// length and may end in CRLF, LF or CR.
+package sse
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Event is a dispatched server-sent event. Type is "message" unless the
+// stream named it with an "event:" field. ID is the last event ID in effect
+// when the event was dispatched; HasID reports whether this event set it with
+// its own valid "id:" field rather than inheriting it. Retry is set only if
+// this event carried a valid "retry:" field.
+type Event struct {
+	ID    string
+	HasID bool
+	Type  string
+	Data  string
+	Retry time.Duration
+}
+
+type Decoder struct {
+	r       *bufio.Reader
+	line    []byte
+	started bool
+	skipLF  bool
+
+	// LastEventID is the event ID buffer, which persists across events.
+	LastEventID string
+	// Retry is the most recent reconnection time sent by the server.
+	Retry time.Duration
+}
+
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: bufio.NewReader(r)}
+}
+
+// Next returns the next event. At the end of the stream it returns io.EOF;
+// any partially received event is discarded, as the spec requires.
+func (d *Decoder) Next() (Event, error) {
+	var (
+		data      strings.Builder
+		eventType string
+		hasID     bool
+		retry     time.Duration
+	)
+
+	for {
+		line, err := d.readLine()
+		if err != nil {
+			return Event{}, err
+		}
+
+		if len(line) == 0 {
+			if data.Len() == 0 {
+				eventType = ""
+				hasID = false
+				retry = 0
+				continue
+			}
+			ev := Event{
+				ID:    d.LastEventID,
+				HasID: hasID,
+				Type:  eventType,
+				Data:  strings.TrimSuffix(data.String(), "\n"),
+				Retry: retry,
+			}
+			if ev.Type == "" {
+				ev.Type = "message"
+			}
+			return ev, nil
+		}
+
+		if line[0] == ':' {
+			continue
+		}
+
+		field, value := line, []byte(nil)
+		if i := bytes.IndexByte(line, ':'); i >= 0 {
+			field, value = line[:i], line[i+1:]
+			if len(value) > 0 && value[0] == ' ' {
+				value = value[1:]
+			}
+		}
+
+		switch string(field) {
+		case "event":
+			eventType = string(value)
+		case "data":
+			data.Write(value)
+			data.WriteByte('\n')
+		case "id":
+			if bytes.IndexByte(value, 0) < 0 {
+				d.LastEventID = string(value)
+				hasID = true
+			}
+		case "retry":
+			if isDigits(value) {
+				if ms, err := strconv.ParseInt(string(value), 10, 64); err == nil && ms <= maxRetryMillis {
+					retry = time.Duration(ms) * time.Millisecond
+					d.Retry = retry
+				}
+			}
+		}
+	}
+}
+
+// readLine returns the next line without its terminator. The returned slice
+// is only valid until the next call.
+func (d *Decoder) readLine() ([]byte, error) {
+	if !d.started {
+		d.started = true
+		if bom, err := d.r.Peek(3); err == nil && bytes.Equal(bom, []byte("\xEF\xBB\xBF")) {
+			_, _ = d.r.Discard(3)
+		}
+	}
+
+	d.line = d.line[:0]
+	for {
+		if d.skipLF {
+			// The previous line ended in CR; a directly following LF belongs
+			// to the same terminator.
+			b, err := d.r.ReadByte()
+			if err != nil {
+				return nil, err
+			}
+			d.skipLF = false
+			if b != '\n' {
+				_ = d.r.UnreadByte()
+			}
+		}
+
+		if d.r.Buffered() == 0 {
+			if _, err := d.r.Peek(1); err != nil {
+				return nil, err
+			}
+		}
+		buf, _ := d.r.Peek(d.r.Buffered())
+
+		if i := bytes.IndexAny(buf, "\r\n"); i >= 0 {
+			d.line = append(d.line, buf[:i]...)
+			d.skipLF = buf[i] == '\r'
+			_, _ = d.r.Discard(i + 1)
+			return d.line, nil
+		}
+		d.line = append(d.line, buf...)
+		_, _ = d.r.Discard(len(buf))
+	}
+}
+
+// maxRetryMillis is the largest retry: value that fits in a time.Duration;
+// larger ones are ignored like malformed ones.
+const maxRetryMillis = int64(1<<63-1) / int64(time.Millisecond)
+
+func isDigits(b []byte) bool {
+	if len(b) == 0 {
+		return false
+	}
+	for _, c := range b {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
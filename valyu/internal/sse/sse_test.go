@@ -0,0 +1,232 @@
+package sse
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+func decodeAll(r io.Reader) ([]Event, error) {
+	d := NewDecoder(r)
+	var events []Event
+	for {
+		ev, err := d.Next()
+		if errors.Is(err, io.EOF) {
+			return events, nil
+		}
+		if err != nil {
+			return events, err
+		}
+		events = append(events, ev)
+	}
+}
+
+func TestDecoder(t *testing.T) {
+	long := strings.Repeat("x", 70*1024)
+	tests := []struct {
+		name   string
+		stream string
+		want   []Event
+	}{
+		{
+			name:   "LF",
+			stream: "data: a\n\ndata: b\n\n",
+			want:   []Event{{Type: "message", Data: "a"}, {Type: "message", Data: "b"}},
+		},
+		{
+			name:   "CRLF",
+			stream: "data: a\r\n\r\ndata: b\r\n\r\n",
+			want:   []Event{{Type: "message", Data: "a"}, {Type: "message", Data: "b"}},
+		},
+		{
+			name:   "CR",
+			stream: "data: a\r\rdata: b\r\r",
+			want:   []Event{{Type: "message", Data: "a"}, {Type: "message", Data: "b"}},
+		},
+		{
+			name:   "mixed line endings",
+			stream: "data: a\rdata: b\r\ndata: c\n\r\n",
+			want:   []Event{{Type: "message", Data: "a\nb\nc"}},
+		},
+		{
+			name:   "leading BOM",
+			stream: "\xEF\xBB\xBFdata: a\n\n",
+			want:   []Event{{Type: "message", Data: "a"}},
+		},
+		{
+			name:   "BOM only stripped at start",
+			stream: "data: a\n\n\xEF\xBB\xBFdata: b\n\n",
+			want:   []Event{{Type: "message", Data: "a"}},
+		},
+		{
+			name:   "multi-line data",
+			stream: "data: first\ndata:second\ndata:  third\ndata\n\n",
+			want:   []Event{{Type: "message", Data: "first\nsecond\n third\n"}},
+		},
+		{
+			name:   "event type resets after dispatch",
+			stream: "event: add\ndata: 1\n\ndata: 2\n\n",
+			want:   []Event{{Type: "add", Data: "1"}, {Type: "message", Data: "2"}},
+		},
+		{
+			name:   "id persists across events",
+			stream: "id: 1\ndata: a\n\ndata: b\n\nid\ndata: c\n\n",
+			want: []Event{
+				{ID: "1", HasID: true, Type: "message", Data: "a"},
+				{ID: "1", Type: "message", Data: "b"},
+				{ID: "", HasID: true, Type: "message", Data: "c"},
+			},
+		},
+		{
+			name:   "id containing NUL is ignored",
+			stream: "id: 1\ndata: a\n\nid: 2\x003\ndata: b\n\n",
+			want: []Event{
+				{ID: "1", HasID: true, Type: "message", Data: "a"},
+				{ID: "1", Type: "message", Data: "b"},
+			},
+		},
+		{
+			name:   "retry",
+			stream: "retry: 1500\ndata: a\n\nretry: 1.5\ndata: b\n\nretry: x\ndata: c\n\nretry:\ndata: d\n\n",
+			want: []Event{
+				{Type: "message", Data: "a", Retry: 1500 * time.Millisecond},
+				{Type: "message", Data: "b"},
+				{Type: "message", Data: "c"},
+				{Type: "message", Data: "d"},
+			},
+		},
+		{
+			name:   "retry overflowing a duration is ignored",
+			stream: "retry: 9223372036854775\ndata: a\n\nretry: 99999999999999999999\ndata: b\n\n",
+			want:   []Event{{Type: "message", Data: "a"}, {Type: "message", Data: "b"}},
+		},
+		{
+			name:   "comments and unknown fields",
+			stream: ": ping\n:\nfoo: bar\ndata: a\n: inner\n\n",
+			want:   []Event{{Type: "message", Data: "a"}},
+		},
+		{
+			name:   "no data dispatches nothing",
+			stream: "event: x\n\nid: 7\n\n: ping\n\ndata: a\n\n",
+			want:   []Event{{ID: "7", Type: "message", Data: "a"}},
+		},
+		{
+			name:   "empty data line dispatches empty event",
+			stream: "data\n\n",
+			want:   []Event{{Type: "message", Data: ""}},
+		},
+		{
+			name:   "field without colon",
+			stream: "data\ndata\n\n",
+			want:   []Event{{Type: "message", Data: "\n"}},
+		},
+		{
+			name:   "only first space stripped",
+			stream: "data:  a \n\n",
+			want:   []Event{{Type: "message", Data: " a "}},
+		},
+		{
+			name:   "incomplete event at EOF is discarded",
+			stream: "data: a\n\ndata: b\n",
+			want:   []Event{{Type: "message", Data: "a"}},
+		},
+		{
+			name:   "line over 64 KiB",
+			stream: "data: " + long + "\n\ndata: after\n\n",
+			want:   []Event{{Type: "message", Data: long}, {Type: "message", Data: "after"}},
+		},
+	}
+	readers := map[string]func(string) io.Reader{
+		"whole":    func(s string) io.Reader { return strings.NewReader(s) },
+		"one byte": func(s string) io.Reader { return iotest.OneByteReader(strings.NewReader(s)) },
+	}
+	for _, tt := range tests {
+		for rname, newReader := range readers {
+			t.Run(tt.name+"/"+rname, func(t *testing.T) {
+				got, err := decodeAll(newReader(tt.stream))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("events =\n%#v\nwant\n%#v", got, tt.want)
+				}
+			})
+		}
+	}
+}
+
+func TestDecoderState(t *testing.T) {
+	d := NewDecoder(strings.NewReader("id: 5\nretry: 250\ndata: a\n\n"))
+	if _, err := d.Next(); err != nil {
+		t.Fatal(err)
+	}
+	if d.LastEventID != "5" {
+		t.Errorf("LastEventID = %q, want 5", d.LastEventID)
+	}
+	if d.Retry != 250*time.Millisecond {
+		t.Errorf("Retry = %v, want 250ms", d.Retry)
+	}
+}
+
+func TestDecoderReadError(t *testing.T) {
+	boom := errors.New("boom")
+	r := io.MultiReader(strings.NewReader("data: a\n\ndata: b"), iotest.ErrReader(boom))
+	got, err := decodeAll(r)
+	if !errors.Is(err, boom) {
+		t.Fatalf("err = %v, want %v", err, boom)
+	}
+	if len(got) != 1 || got[0].Data != "a" {
+		t.Errorf("events = %#v, want just a", got)
+	}
+}
+
+// FuzzDecoder checks that the decoder never panics, that the way input is
+// split across reads does not change the events, and that every event
+// honours the invariants of the spec.
+func FuzzDecoder(f *testing.F) {
+	for _, seed := range []string{
+		"data: a\n\n",
+		"data: a\r\n\r\n",
+		"data: a\r\r",
+		"\xEF\xBB\xBFevent: x\ndata: 1\ndata: 2\n\n",
+		"id: 1\x00\ndata\n\n",
+		"retry: 10\n: c\n\n",
+		"data: a\r\ndata: b\r\rid: 9\n\n",
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, stream string) {
+		whole, err := decodeAll(strings.NewReader(stream))
+		if err != nil {
+			t.Fatal(err)
+		}
+		bytewise, err := decodeAll(iotest.OneByteReader(strings.NewReader(stream)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(whole, bytewise) {
+			t.Fatalf("whole = %#v, bytewise = %#v", whole, bytewise)
+		}
+		for _, ev := range whole {
+			if ev.Type == "" {
+				t.Errorf("event %#v has no type", ev)
+			}
+			if ev.HasID && !strings.Contains(stream, "id") {
+				t.Errorf("event %#v has its own id but the stream has no id field", ev)
+			}
+			if strings.ContainsAny(ev.Type, "\r\n") || strings.ContainsAny(ev.ID, "\r\n\x00") {
+				t.Errorf("event %#v has a line break or NUL in type or id", ev)
+			}
+			if strings.Contains(ev.Data, "\r") {
+				t.Errorf("event %#v keeps a CR in data", ev)
+			}
+			if ev.Retry < 0 {
+				t.Errorf("event %#v has negative retry", ev)
+			}
+		}
+	})
+}
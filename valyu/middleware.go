@@ -0,0 +1,18 @@
+package valyu
+
+import "github.com/Veri5ied/valyu-go/valyu/internal/api"
+
+// Request is the view of an SDK call passed to middleware: the HTTP method,
+// endpoint path (e.g. "/search"), the request struct that will be encoded as
+// JSON, extra headers, and whether the call streams server-sent events.
+type Request = api.Request
+
+// Response carries the raw *http.Response and, for non-streaming calls, the
+// decoded result.
+type Response = api.Response
+
+type Handler = api.Handler
+
+// Middleware intercepts every Post, Get and streaming call made by the
+// client's services.
+type Middleware = api.Middleware
@@ -0,0 +1,74 @@
+// Package option provides per-call settings accepted by every service method,
+// merged on top of the client defaults.
+//
+//	resp, err := client.Search.Search(ctx, "query", nil,
+//		option.WithAPIKey(tenantKey),
+//		option.WithTimeout(5*time.Second),
+//	)
+package option
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Veri5ied/valyu-go/valyu/common"
+	"github.com/Veri5ied/valyu-go/valyu/internal/api"
+)
+
+type RequestOption = api.RequestOption
+
+// WithHeader sets a request header, replacing any value set by the SDK.
+func WithHeader(key, value string) RequestOption {
+	return func(r *api.Request) {
+		r.Header.Set(key, value)
+	}
+}
+
+// WithHeaders sets several request headers at once.
+func WithHeaders(headers http.Header) RequestOption {
+	return func(r *api.Request) {
+		for k, vs := range headers {
+			r.Header[http.CanonicalHeaderKey(k)] = append([]string(nil), vs...)
+		}
+	}
+}
+
+// WithAPIKey sends the call with a different API key than the client's.
+func WithAPIKey(apiKey string) RequestOption {
+	return func(r *api.Request) {
+		r.APIKey = apiKey
+	}
+}
+
+// WithBaseURL sends the call to a different API base URL.
+func WithBaseURL(baseURL string) RequestOption {
+	return func(r *api.Request) {
+		r.BaseURL = strings.TrimSuffix(baseURL, "/")
+	}
+}
+
+// WithTimeout bounds the whole call, including retries. For streaming calls
+// the timeout also covers reading the stream.
+func WithTimeout(timeout time.Duration) RequestOption {
+	return func(r *api.Request) {
+		r.Timeout = timeout
+	}
+}
+
+// WithIdempotencyKey sets the Idempotency-Key header. Without it, one is
+// generated for POST requests when retries are enabled.
+func WithIdempotencyKey(key string) RequestOption {
+	return func(r *api.Request) {
+		r.IdempotencyKey = key
+	}
+}
+
+// WithResponseInto fills meta with the status code, headers, request ID,
+// rate-limit quota and server timing of the response. For streaming calls it
+// is filled once the stream opens.
+func WithResponseInto(meta *common.ResponseMeta) RequestOption {
+	return func(r *api.Request) {
+		r.ResponseMeta = meta
+	}
+}
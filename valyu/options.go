@@ -1,28 +1,204 @@
 package valyu
 
 import (
+	"crypto/tls"
+	"log/slog"
 	"net/http"
 	"time"
+
+	"github.com/Veri5ied/valyu-go/valyu/common"
+	"github.com/Veri5ied/valyu-go/valyu/internal/api"
 )
 
 type Option func(*Client)
 
+// RetryPolicy configures automatic retries of transient failures. POST
+// requests carry an Idempotency-Key header that is reused across attempts.
+type RetryPolicy = api.RetryPolicy
+
+// ResponseMeta describes the HTTP envelope of a call; see
+// option.WithResponseInto.
+type ResponseMeta = common.ResponseMeta
+
+// Limit throttles requests: RequestsPerSecond with bursts of up to Burst, and
+// at most MaxInFlight concurrent requests. Zero fields mean no limit.
+type Limit = api.Limit
+
+// Endpoint groups accepted by WithEndpointLimit.
+const (
+	EndpointSearch       = "search"
+	EndpointAnswer       = "answer"
+	EndpointContents     = "contents"
+	EndpointDeepResearch = "deepresearch"
+	EndpointBatch        = "batch"
+	EndpointDatasources  = "datasources"
+)
+
+// DefaultRetryPolicy retries up to 3 attempts on 408, 429 and 5xx gateway
+// errors as well as connection resets and timeouts.
+var DefaultRetryPolicy = api.DefaultRetryPolicy
+
 func WithBaseURL(url string) Option {
 	return func(c *Client) {
 		c.baseURL = url
 	}
 }
 
+// WithHTTPClient uses a copy of httpClient for all requests; the original is
+// never modified. WithTimeout and the transport options apply on top of it
+// regardless of the order in which options are given.
 func WithHTTPClient(httpClient *http.Client) Option {
 	return func(c *Client) {
 		c.httpClient = httpClient
 	}
 }
 
+// WithTimeout sets the overall timeout of each HTTP request. It defaults to
+// DefaultTimeout, or to the timeout of the client given to WithHTTPClient.
 func WithTimeout(timeout time.Duration) Option {
 	return func(c *Client) {
-		if c.httpClient != nil {
-			c.httpClient.Timeout = timeout
+		c.timeout = &timeout
+	}
+}
+
+// WithProxy routes requests through the proxy at rawURL, e.g.
+// "http://proxy.internal:3128".
+func WithProxy(rawURL string) Option {
+	return func(c *Client) {
+		c.transport.proxyURL = rawURL
+	}
+}
+
+// WithTLSConfig sets the TLS configuration used to reach the API.
+func WithTLSConfig(config *tls.Config) Option {
+	return func(c *Client) {
+		c.transport.tlsConfig = config
+	}
+}
+
+// WithCABundle trusts only the PEM certificates in the file at path.
+func WithCABundle(path string) Option {
+	return func(c *Client) {
+		c.transport.caBundle = path
+	}
+}
+
+// WithMaxIdleConns sets the size of the connection pool, in total and per
+// host.
+func WithMaxIdleConns(total, perHost int) Option {
+	return func(c *Client) {
+		c.transport.maxIdleConns = total
+		c.transport.maxIdlePerHost = perHost
+	}
+}
+
+// WithHTTP2(false) restricts the client to HTTP/1.1. HTTP/2 is already
+// negotiated by default; WithHTTP2(true) only matters for a transport passed
+// via WithHTTPClient that has ForceAttemptHTTP2 unset, such as one with a
+// custom dialer or TLS config.
+func WithHTTP2(enabled bool) Option {
+	return func(c *Client) {
+		c.transport.http2 = &enabled
+	}
+}
+
+// WithDialTimeout bounds establishing the TCP connection and TLS handshake.
+func WithDialTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.transport.dialTimeout = timeout
+	}
+}
+
+// WithResponseHeaderTimeout bounds the wait for response headers after the
+// request is written. Unlike WithTimeout it does not limit how long an answer
+// stream may run.
+func WithResponseHeaderTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.transport.responseTimeout = timeout
+	}
+}
+
+// WithUserAgent appends suffix to the SDK's User-Agent, e.g. "my-app/2.1".
+func WithUserAgent(suffix string) Option {
+	return func(c *Client) {
+		c.userAgent = suffix
+	}
+}
+
+func WithRetry(policy RetryPolicy) Option {
+	return func(c *Client) {
+		c.retry = policy
+	}
+}
+
+// WithSuccessCheck controls whether responses that report "success": false
+// are returned as a *ResponseError. It is enabled by default; pass false to
+// get the decoded response back with a nil error and inspect Success yourself.
+func WithSuccessCheck(enabled bool) Option {
+	return func(c *Client) {
+		c.checkSuccess = enabled
+	}
+}
+
+// WithMiddleware appends middleware to the client's chain. It may be given
+// more than once; middleware registered first runs outermost.
+func WithMiddleware(middleware ...Middleware) Option {
+	return func(c *Client) {
+		c.middleware = append(c.middleware, middleware...)
+	}
+}
+
+// WithLogger makes the transport log each request's method, path, status,
+// latency, attempts and tx_id. Retries and failures are logged at warn level.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *Client) {
+		c.logger = logger
+	}
+}
+
+// WithLogLevel sets the level of successful request logs. The default is
+// slog.LevelInfo.
+func WithLogLevel(level slog.Level) Option {
+	return func(c *Client) {
+		c.logLevel = level
+	}
+}
+
+// WithDebug additionally logs request and response bodies at debug level.
+// API keys, MCP auth tokens and headers, webhook secrets and headers whose
+// names contain auth, token, key, secret, password, cookie, session or
+// signature are redacted.
+func WithDebug(enabled bool) Option {
+	return func(c *Client) {
+		c.debug = enabled
+	}
+}
+
+// WithRateLimit limits all requests made by the client, across services, to
+// rps per second with bursts of up to burst. Callers block until a token is
+// available or their context is done.
+func WithRateLimit(rps float64, burst int) Option {
+	return func(c *Client) {
+		c.limit.RequestsPerSecond = rps
+		c.limit.Burst = burst
+	}
+}
+
+// WithMaxInFlight caps the number of concurrent requests, including open
+// answer streams.
+func WithMaxInFlight(n int) Option {
+	return func(c *Client) {
+		c.limit.MaxInFlight = n
+	}
+}
+
+// WithEndpointLimit applies limit to one endpoint group, such as
+// EndpointDeepResearch, in addition to the client-wide limits.
+func WithEndpointLimit(group string, limit Limit) Option {
+	return func(c *Client) {
+		if c.groupLimits == nil {
+			c.groupLimits = make(map[string]api.Limit)
 		}
+		c.groupLimits[group] = limit
 	}
 }
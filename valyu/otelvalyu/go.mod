@@ -0,0 +1,22 @@
+module github.com/Veri5ied/valyu-go/valyu/otelvalyu
+
+go 1.21
+
+require (
+	github.com/Veri5ied/valyu-go v0.0.0-00010101000000-000000000000
+	go.opentelemetry.io/otel v1.28.0
+	go.opentelemetry.io/otel/metric v1.28.0
+	go.opentelemetry.io/otel/sdk v1.28.0
+	go.opentelemetry.io/otel/trace v1.28.0
+)
+
+require (
+	github.com/go-logr/logr v1.4.2 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	github.com/google/uuid v1.6.0 // indirect
+	golang.org/x/sys v0.21.0 // indirect
+)
+
+// Until the core module tags a release with the APIs this package uses, it
+// is built from the checkout it lives in.
+replace github.com/Veri5ied/valyu-go => ../..
@@ -0,0 +1,343 @@
+// Package otelvalyu instruments a valyu.Client with OpenTelemetry traces and
+// metrics. It lives in its own module so the core SDK stays free of
+// third-party dependencies.
+//
+//	client, err := valyu.New("", valyu.WithMiddleware(otelvalyu.Middleware()))
+package otelvalyu
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/Veri5ied/valyu-go/valyu"
+	"github.com/Veri5ied/valyu-go/valyu/answer"
+	"github.com/Veri5ied/valyu-go/valyu/batch"
+	"github.com/Veri5ied/valyu-go/valyu/common"
+	"github.com/Veri5ied/valyu-go/valyu/contents"
+	"github.com/Veri5ied/valyu-go/valyu/datasources"
+	"github.com/Veri5ied/valyu-go/valyu/deepresearch"
+	"github.com/Veri5ied/valyu-go/valyu/search"
+)
+
+const instrumentationName = "github.com/Veri5ied/valyu-go/valyu/otelvalyu"
+
+const (
+	AttrOperation     = attribute.Key("valyu.operation")
+	AttrEndpoint      = attribute.Key("valyu.endpoint")
+	AttrSearchType    = attribute.Key("valyu.search_type")
+	AttrMode          = attribute.Key("valyu.mode")
+	AttrResultCount   = attribute.Key("valyu.result_count")
+	AttrTxID          = attribute.Key("valyu.tx_id")
+	AttrCostDollars   = attribute.Key("valyu.cost_dollars")
+	AttrInputTokens   = attribute.Key("valyu.usage.input_tokens")
+	AttrOutputTokens  = attribute.Key("valyu.usage.output_tokens")
+	AttrResearchID    = attribute.Key("valyu.deepresearch_id")
+	AttrBatchID       = attribute.Key("valyu.batch_id")
+	AttrStatus        = attribute.Key("valyu.status")
+	AttrStatusCode    = attribute.Key("http.response.status_code")
+	AttrRequestMethod = attribute.Key("http.request.method")
+	AttrErrorType     = attribute.Key("error.type")
+)
+
+type config struct {
+	tracerProvider trace.TracerProvider
+	meterProvider  metric.MeterProvider
+}
+
+type Option func(*config)
+
+// WithTracerProvider sets the provider used to create spans. It defaults to
+// the global provider.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(c *config) {
+		c.tracerProvider = tp
+	}
+}
+
+// WithMeterProvider sets the provider used to create instruments. It defaults
+// to the global provider.
+func WithMeterProvider(mp metric.MeterProvider) Option {
+	return func(c *config) {
+		c.meterProvider = mp
+	}
+}
+
+type instrumentation struct {
+	tracer   trace.Tracer
+	duration metric.Float64Histogram
+	cost     metric.Float64Histogram
+	tokens   metric.Int64Counter
+}
+
+// Middleware returns a valyu.Middleware that opens a client span for every
+// service call and records latency and cost histograms. Spans for streaming
+// calls stay open until the event stream is fully read or closed.
+func Middleware(opts ...Option) valyu.Middleware {
+	cfg := config{
+		tracerProvider: otel.GetTracerProvider(),
+		meterProvider:  otel.GetMeterProvider(),
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	meter := cfg.meterProvider.Meter(instrumentationName)
+	inst := &instrumentation{tracer: cfg.tracerProvider.Tracer(instrumentationName)}
+
+	var err error
+	inst.duration, err = meter.Float64Histogram("valyu.client.duration",
+		metric.WithDescription("Duration of Valyu API calls, including the full event stream for streaming calls."),
+		metric.WithUnit("s"))
+	if err != nil {
+		otel.Handle(err)
+	}
+	inst.cost, err = meter.Float64Histogram("valyu.client.cost",
+		metric.WithDescription("Dollar cost reported by the Valyu API per call."),
+		metric.WithUnit("{USD}"))
+	if err != nil {
+		otel.Handle(err)
+	}
+	inst.tokens, err = meter.Int64Counter("valyu.client.tokens",
+		metric.WithDescription("AI tokens consumed by Valyu answer calls."),
+		metric.WithUnit("{token}"))
+	if err != nil {
+		otel.Handle(err)
+	}
+
+	return inst.middleware
+}
+
+func (inst *instrumentation) middleware(next valyu.Handler) valyu.Handler {
+	return func(ctx context.Context, req *valyu.Request) (*valyu.Response, error) {
+		op := Operation(req.Method, req.Path)
+		base := []attribute.KeyValue{
+			AttrOperation.String(op),
+			AttrEndpoint.String(req.Path),
+			AttrRequestMethod.String(req.Method),
+		}
+
+		ctx, span := inst.tracer.Start(ctx, op,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(base...),
+			trace.WithAttributes(requestAttributes(req.Body)...))
+		start := time.Now()
+
+		resp, err := next(ctx, req)
+
+		if resp != nil && resp.HTTPResponse != nil {
+			code := resp.HTTPResponse.StatusCode
+			span.SetAttributes(AttrStatusCode.Int(code))
+			if err == nil && code >= 400 {
+				// An inner middleware let an error status through; the
+				// body is not a result, so report it as a failure.
+				err = &common.APIError{StatusCode: code, Message: http.StatusText(code)}
+				resp.HTTPResponse.Body.Close()
+				inst.finish(ctx, span, start, base, result{}, err)
+				return resp, err
+			}
+		}
+
+		if err == nil && req.Stream && resp != nil && resp.HTTPResponse != nil {
+			rec := &streamRecorder{
+				inst:  inst,
+				ctx:   ctx,
+				span:  span,
+				start: start,
+				attrs: base,
+			}
+			resp.HTTPResponse.Body = rec.wrap(resp.HTTPResponse.Body)
+			return resp, nil
+		}
+
+		var out result
+		if resp != nil {
+			out = resultAttributes(resp.Result)
+			span.SetAttributes(out.attrs...)
+		}
+		inst.finish(ctx, span, start, base, out, err)
+		return resp, err
+	}
+}
+
+type result struct {
+	attrs        []attribute.KeyValue
+	cost         float64
+	inputTokens  int
+	outputTokens int
+}
+
+func (inst *instrumentation) finish(ctx context.Context, span trace.Span, start time.Time, base []attribute.KeyValue, out result, err error) {
+	attrs := append([]attribute.KeyValue(nil), base...)
+	if err != nil {
+		errType := errorType(err)
+		attrs = append(attrs, AttrErrorType.String(errType))
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		span.SetAttributes(AttrErrorType.String(errType))
+	}
+
+	set := metric.WithAttributes(attrs...)
+	if inst.duration != nil {
+		inst.duration.Record(ctx, time.Since(start).Seconds(), set)
+	}
+	if inst.cost != nil && out.cost > 0 {
+		inst.cost.Record(ctx, out.cost, set)
+	}
+	if inst.tokens != nil {
+		if out.inputTokens > 0 {
+			inst.tokens.Add(ctx, int64(out.inputTokens), metric.WithAttributes(append(attrs, attribute.String("valyu.token.type", "input"))...))
+		}
+		if out.outputTokens > 0 {
+			inst.tokens.Add(ctx, int64(out.outputTokens), metric.WithAttributes(append(attrs, attribute.String("valyu.token.type", "output"))...))
+		}
+	}
+	span.End()
+}
+
+// Operation maps an endpoint to the SDK method that calls it, e.g.
+// "POST /search" to "search.Search". It is used as the span name.
+func Operation(method, path string) string {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	resource := parts[0]
+	hasID := len(parts) > 1 && parts[1] != ""
+
+	switch resource {
+	case "search":
+		return "search.Search"
+	case "contents":
+		return "contents.Get"
+	case "answer":
+		return "answer.Stream"
+	case "datasources":
+		return "datasources.List"
+	case "deepresearch", "batch":
+		switch {
+		case method == http.MethodPost:
+			return resource + ".Create"
+		case hasID:
+			return resource + ".Get"
+		default:
+			return resource + ".List"
+		}
+	}
+	return "valyu " + method + " " + path
+}
+
+func requestAttributes(body interface{}) []attribute.KeyValue {
+	var searchType, mode string
+	switch b := body.(type) {
+	case search.Request:
+		if b.Options != nil {
+			searchType = string(b.SearchType)
+		}
+	case *search.Options:
+		searchType = string(b.SearchType)
+	case answer.Request:
+		searchType = string(b.SearchType)
+	case *answer.Options:
+		searchType = string(b.SearchType)
+	case *deepresearch.CreateOptions:
+		mode = string(b.Mode)
+		if b.Search != nil {
+			searchType = b.Search.SearchType
+		}
+	case *batch.CreateOptions:
+		mode = string(b.Mode)
+		if b.Search != nil {
+			searchType = b.Search.SearchType
+		}
+	}
+
+	var attrs []attribute.KeyValue
+	if searchType != "" {
+		attrs = append(attrs, AttrSearchType.String(searchType))
+	}
+	if mode != "" {
+		attrs = append(attrs, AttrMode.String(mode))
+	}
+	return attrs
+}
+
+func resultAttributes(v interface{}) result {
+	var out result
+	add := func(kv ...attribute.KeyValue) {
+		out.attrs = append(out.attrs, kv...)
+	}
+	addString := func(key attribute.Key, s string) {
+		if s != "" {
+			add(key.String(s))
+		}
+	}
+
+	switch r := v.(type) {
+	case *search.Response:
+		add(AttrResultCount.Int(len(r.Results)), AttrCostDollars.Float64(r.TotalDeductionDollars))
+		addString(AttrTxID, r.TxID)
+		out.cost = r.TotalDeductionDollars
+	case *contents.Response:
+		add(AttrResultCount.Int(len(r.Results)), AttrCostDollars.Float64(r.TotalCostDollars))
+		addString(AttrTxID, r.TxID)
+		out.cost = r.TotalCostDollars
+	case *deepresearch.CreateResponse:
+		addString(AttrResearchID, r.DeepResearchID)
+		addString(AttrStatus, string(r.Status))
+	case *deepresearch.StatusResponse:
+		addString(AttrResearchID, r.DeepResearchID)
+		addString(AttrStatus, string(r.Status))
+		cost := r.Cost
+		if r.Usage != nil && r.Usage.TotalCost > 0 {
+			cost = r.Usage.TotalCost
+		}
+		if cost > 0 {
+			add(AttrCostDollars.Float64(cost))
+		}
+		out.cost = cost
+	case *deepresearch.ListResponse:
+		add(AttrResultCount.Int(len(r.Data)))
+	case *batch.CreateResponse:
+		addString(AttrBatchID, r.BatchID)
+		addString(AttrStatus, string(r.Status))
+	case *batch.StatusResponse:
+		if r.Batch != nil {
+			addString(AttrBatchID, r.Batch.BatchID)
+			addString(AttrStatus, string(r.Batch.Status))
+			if r.Batch.Cost > 0 {
+				add(AttrCostDollars.Float64(r.Batch.Cost))
+			}
+			out.cost = r.Batch.Cost
+		}
+	case *batch.ListResponse:
+		add(AttrResultCount.Int(len(r.Batches)))
+	case *datasources.ListResponse:
+		add(AttrResultCount.Int(len(r.Datasources)))
+	}
+	return out
+}
+
+func errorType(err error) string {
+	if apiErr, ok := common.AsAPIError(err); ok {
+		if kind := apiErr.Kind(); kind != nil {
+			return strings.TrimPrefix(kind.Error(), "valyu: ")
+		}
+		return http.StatusText(apiErr.StatusCode)
+	}
+	if _, ok := common.AsResponseError(err); ok {
+		return "response error"
+	}
+	if errors.Is(err, context.Canceled) {
+		return "canceled"
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return "deadline exceeded"
+	}
+	return "transport"
+}
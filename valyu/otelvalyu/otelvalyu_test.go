@@ -0,0 +1,213 @@
+package otelvalyu
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/Veri5ied/valyu-go/valyu"
+	"github.com/Veri5ied/valyu-go/valyu/answer"
+	"github.com/Veri5ied/valyu-go/valyu/common"
+	"github.com/Veri5ied/valyu-go/valyu/deepresearch"
+	"github.com/Veri5ied/valyu-go/valyu/search"
+)
+
+func newTestClient(t *testing.T, h http.HandlerFunc) (*valyu.Client, *tracetest.SpanRecorder) {
+	t.Helper()
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+
+	rec := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec))
+	client, err := valyu.New("test-key",
+		valyu.WithBaseURL(srv.URL),
+		valyu.WithMiddleware(Middleware(WithTracerProvider(tp))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client, rec
+}
+
+func spanAttr(span sdktrace.ReadOnlySpan, key attribute.Key) (attribute.Value, bool) {
+	for _, kv := range span.Attributes() {
+		if kv.Key == key {
+			return kv.Value, true
+		}
+	}
+	return attribute.Value{}, false
+}
+
+func TestAnswerStreamSpanStatus(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		body       string
+		wantCode   codes.Code
+		wantDesc   string
+		wantStatus int64
+	}{
+		{
+			name:       "ok",
+			status:     http.StatusOK,
+			body:       "data: {\"search_results\":[{},{}]}\n\ndata: {\"tx_id\":\"tx\",\"cost\":{\"total_deduction_dollars\":0.01}}\n\ndata: [DONE]\n\n",
+			wantCode:   codes.Unset,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "error payload",
+			status:     http.StatusOK,
+			body:       "data: {\"error\":\"model overloaded\"}\n\ndata: [DONE]\n\n",
+			wantCode:   codes.Error,
+			wantDesc:   "model overloaded",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "success false",
+			status:     http.StatusOK,
+			body:       "data: {\"success\":false,\"error\":\"insufficient credits\"}\n\ndata: [DONE]\n\n",
+			wantCode:   codes.Error,
+			wantDesc:   "insufficient credits",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "no done event",
+			status:     http.StatusOK,
+			body:       "data: {\"choices\":[{\"delta\":{\"content\":\"partial\"}}]}\n\n",
+			wantCode:   codes.Error,
+			wantDesc:   answer.ErrStreamIncomplete.Error(),
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "error status",
+			status:     http.StatusUnauthorized,
+			body:       `{"error":"bad key"}`,
+			wantCode:   codes.Error,
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "error event",
+			status:     http.StatusOK,
+			body:       "event: error\ndata: model overloaded\n\n",
+			wantCode:   codes.Error,
+			wantStatus: http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, rec := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				io.WriteString(w, tt.body)
+			})
+
+			st, err := client.Answer.OpenStream(context.Background(), "q", nil)
+			if err == nil {
+				for st.Next() {
+				}
+				st.Close()
+			}
+
+			spans := rec.Ended()
+			if len(spans) != 1 {
+				t.Fatalf("got %d ended spans, want 1", len(spans))
+			}
+			span := spans[0]
+			if got := span.Status().Code; got != tt.wantCode {
+				t.Errorf("status = %v (%q), want %v", got, span.Status().Description, tt.wantCode)
+			}
+			if got := span.Status().Description; tt.wantDesc != "" && got != tt.wantDesc {
+				t.Errorf("status description = %q, want %q", got, tt.wantDesc)
+			}
+			if v, ok := spanAttr(span, AttrStatusCode); !ok || v.AsInt64() != tt.wantStatus {
+				t.Errorf("%s = %v, want %d", AttrStatusCode, v.Emit(), tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestErrorStatusFromInnerMiddleware(t *testing.T) {
+	// A middleware that turns errors back into plain responses must not hide
+	// the failure from the span.
+	swallow := func(next valyu.Handler) valyu.Handler {
+		return func(ctx context.Context, req *valyu.Request) (*valyu.Response, error) {
+			resp, _ := next(ctx, req)
+			return resp, nil
+		}
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		io.WriteString(w, `{"error":"bad request"}`)
+	}))
+	defer srv.Close()
+
+	rec := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec))
+	client, err := valyu.New("test-key",
+		valyu.WithBaseURL(srv.URL),
+		valyu.WithMiddleware(Middleware(WithTracerProvider(tp)), swallow))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	st, err := client.Answer.OpenStream(context.Background(), "q", nil)
+	if err == nil {
+		st.Close()
+		t.Fatal("OpenStream succeeded, want error")
+	}
+	if apiErr, ok := common.AsAPIError(err); !ok || apiErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("err = %v, want status 400", err)
+	}
+	spans := rec.Ended()
+	if len(spans) != 1 || spans[0].Status().Code != codes.Error {
+		t.Fatalf("spans = %v, want one failed span", spans)
+	}
+}
+
+func TestRequestAttributes(t *testing.T) {
+	tests := []struct {
+		name string
+		body interface{}
+		want map[attribute.Key]string
+	}{
+		{"nil", nil, map[attribute.Key]string{}},
+		{"search without options", search.Request{Query: "q"}, map[attribute.Key]string{}},
+		{
+			"search",
+			search.Request{Query: "q", Options: &search.Options{SearchType: common.SearchTypeWeb}},
+			map[attribute.Key]string{AttrSearchType: "web"},
+		},
+		{
+			"answer",
+			answer.Request{Query: "q", Options: answer.Options{SearchType: common.SearchTypeAll}},
+			map[attribute.Key]string{AttrSearchType: "all"},
+		},
+		{
+			"deep research",
+			&deepresearch.CreateOptions{Query: "q", Mode: common.DeepResearchModeFast, Search: &deepresearch.SearchConfig{SearchType: "proprietary"}},
+			map[attribute.Key]string{AttrMode: "fast", AttrSearchType: "proprietary"},
+		},
+		{"unknown body", map[string]string{"search_type": "web"}, map[attribute.Key]string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := map[attribute.Key]string{}
+			for _, kv := range requestAttributes(tt.body) {
+				got[kv.Key] = kv.Value.AsString()
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("attributes = %v, want %v", got, tt.want)
+			}
+			for k, v := range tt.want {
+				if got[k] != v {
+					t.Errorf("%s = %q, want %q", k, got[k], v)
+				}
+			}
+		})
+	}
+}
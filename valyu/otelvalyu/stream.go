@@ -0,0 +1,165 @@
+package otelvalyu
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/Veri5ied/valyu-go/valyu/answer"
+)
+
+// streamRecorder watches an SSE body as the caller reads it, picking up
+// search results and metadata events, and ends the span when the stream is
+// exhausted or closed.
+type streamRecorder struct {
+	inst  *instrumentation
+	ctx   context.Context
+	span  trace.Span
+	start time.Time
+	attrs []attribute.KeyValue
+
+	body    io.ReadCloser
+	line    []byte
+	results int
+	out     result
+	once    sync.Once
+
+	// eventType is the type of the event being read; streamErr holds an
+	// error the server reported after a 200 status, and done is set once
+	// the final [DONE] event is seen.
+	eventType string
+	streamErr error
+	done      bool
+}
+
+func (r *streamRecorder) wrap(body io.ReadCloser) io.ReadCloser {
+	r.body = body
+	return r
+}
+
+func (r *streamRecorder) Read(p []byte) (int, error) {
+	n, err := r.body.Read(p)
+	r.scan(p[:n])
+	if err == io.EOF {
+		var incomplete error
+		if !r.done {
+			incomplete = answer.ErrStreamIncomplete
+		}
+		r.end(incomplete)
+	} else if err != nil {
+		r.end(err)
+	}
+	return n, err
+}
+
+func (r *streamRecorder) Close() error {
+	err := r.body.Close()
+	r.end(nil)
+	return err
+}
+
+func (r *streamRecorder) scan(p []byte) {
+	for len(p) > 0 {
+		i := bytes.IndexByte(p, '\n')
+		if i < 0 {
+			r.line = append(r.line, p...)
+			return
+		}
+		r.line = append(r.line, p[:i]...)
+		r.event(bytes.TrimRight(r.line, "\r"))
+		r.line = r.line[:0]
+		p = p[i+1:]
+	}
+}
+
+func (r *streamRecorder) event(line []byte) {
+	if len(line) == 0 {
+		r.eventType = ""
+		return
+	}
+	if typ, ok := bytes.CutPrefix(line, []byte("event:")); ok {
+		r.eventType = string(bytes.TrimSpace(typ))
+		return
+	}
+	data, ok := bytes.CutPrefix(line, []byte("data:"))
+	if !ok {
+		return
+	}
+	data = bytes.TrimSpace(data)
+	if string(data) == "[DONE]" {
+		r.done = true
+		return
+	}
+	chunk, ok := answer.ParseChunk(string(data))
+	if !ok && r.eventType == "error" {
+		chunk, ok = answer.StreamChunk{Type: answer.ChunkError, Error: string(data)}, true
+	}
+	if ok && chunk.Type == answer.ChunkError {
+		msg := chunk.Error
+		if msg == "" {
+			msg = "answer stream reported an error"
+		}
+		r.streamErr = errors.New(msg)
+		return
+	}
+	if len(data) == 0 || data[0] != '{' {
+		return
+	}
+
+	var ev struct {
+		TxID          string            `json:"tx_id"`
+		SearchResults []json.RawMessage `json:"search_results"`
+		Cost          *struct {
+			TotalDeductionDollars float64 `json:"total_deduction_dollars"`
+		} `json:"cost"`
+		AIUsage *struct {
+			InputTokens  int `json:"input_tokens"`
+			OutputTokens int `json:"output_tokens"`
+		} `json:"ai_usage"`
+	}
+	if json.Unmarshal(data, &ev) != nil {
+		return
+	}
+
+	r.results += len(ev.SearchResults)
+	if ev.TxID != "" {
+		r.out.attrs = append(r.out.attrs, AttrTxID.String(ev.TxID))
+	}
+	if ev.Cost != nil {
+		r.out.cost = ev.Cost.TotalDeductionDollars
+	}
+	if ev.AIUsage != nil {
+		r.out.inputTokens = ev.AIUsage.InputTokens
+		r.out.outputTokens = ev.AIUsage.OutputTokens
+	}
+}
+
+func (r *streamRecorder) end(err error) {
+	r.once.Do(func() {
+		r.span.SetAttributes(AttrResultCount.Int(r.results))
+		r.span.SetAttributes(r.out.attrs...)
+		if r.out.cost > 0 {
+			r.span.SetAttributes(AttrCostDollars.Float64(r.out.cost))
+		}
+		if r.out.inputTokens > 0 || r.out.outputTokens > 0 {
+			r.span.SetAttributes(
+				AttrInputTokens.Int(r.out.inputTokens),
+				AttrOutputTokens.Int(r.out.outputTokens),
+			)
+		}
+		if errors.Is(err, context.Canceled) {
+			err = nil
+		}
+		if r.streamErr != nil {
+			err = r.streamErr
+		}
+		r.inst.finish(r.ctx, r.span, r.start, r.attrs, r.out, err)
+	})
+}
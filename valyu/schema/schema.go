@@ -0,0 +1,411 @@
+// Package schema derives JSON Schemas (draft 2020-12) from Go types and
+// validates decoded JSON against them. A schema built here can be passed as
+// answer.Options.StructuredOutput, contents.Options.Summary or a
+// deepresearch deliverable, so an extraction shape is defined once as a Go
+// type.
+//
+// Struct fields are named by their json tag. Fields without omitempty are
+// required unless they are pointers. A jsonschema tag adds detail:
+//
+//	type Paper struct {
+//		Title  string    `json:"title" jsonschema:"description=Paper title"`
+//		Status string    `json:"status" jsonschema:"enum=draft,enum=published"`
+//		DOI    *string   `json:"doi,omitempty" jsonschema:"required"`
+//		Date   time.Time `json:"date"`
+//	}
+//
+// Commas inside a description are escaped as \,.
+package schema
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Draft is the JSON Schema dialect produced by Generate.
+const Draft = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema is the subset of JSON Schema needed to describe Go types.
+// AdditionalProperties holds either a bool or a *Schema.
+type Schema struct {
+	Schema               string             `json:"$schema,omitempty"`
+	Ref                  string             `json:"$ref,omitempty"`
+	AnyOf                []*Schema          `json:"anyOf,omitempty"`
+	Defs                 map[string]*Schema `json:"$defs,omitempty"`
+	Type                 Type               `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties interface{}        `json:"additionalProperties,omitempty"`
+}
+
+// Type is a JSON Schema type keyword. It is encoded as a string when it
+// holds one type and as an array otherwise.
+type Type []string
+
+func (t Type) MarshalJSON() ([]byte, error) {
+	if len(t) == 1 {
+		return json.Marshal(t[0])
+	}
+	return json.Marshal([]string(t))
+}
+
+func (t *Type) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*t = Type{s}
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(t))
+}
+
+func (t Type) has(name string) bool {
+	for _, n := range t {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// For returns the schema of T.
+func For[T any]() (*Schema, error) {
+	return Generate(reflect.TypeOf((*T)(nil)).Elem())
+}
+
+// MustFor is like For but panics if T cannot be described, which makes it
+// usable in option literals.
+func MustFor[T any]() *Schema {
+	s, err := For[T]()
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// Generate returns the schema of t. Recursive struct types are placed in
+// $defs and referenced with $ref.
+func Generate(t reflect.Type) (*Schema, error) {
+	g := &generator{
+		visiting:  map[reflect.Type]bool{},
+		recursive: map[reflect.Type]bool{},
+		names:     map[reflect.Type]string{},
+		defs:      map[string]*Schema{},
+	}
+	s, err := g.generate(t)
+	if err != nil {
+		return nil, err
+	}
+	if s.Ref != "" {
+		s = &Schema{Ref: s.Ref}
+	}
+	s.Schema = Draft
+	if len(g.defs) > 0 {
+		s.Defs = g.defs
+	}
+	return s, nil
+}
+
+var (
+	timeType            = reflect.TypeOf(time.Time{})
+	rawMessageType      = reflect.TypeOf(json.RawMessage(nil))
+	jsonMarshalerType   = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+type generator struct {
+	visiting  map[reflect.Type]bool
+	recursive map[reflect.Type]bool
+	names     map[reflect.Type]string
+	defs      map[string]*Schema
+}
+
+func (g *generator) generate(t reflect.Type) (*Schema, error) {
+	if t.Kind() == reflect.Pointer {
+		s, err := g.generate(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return nullable(s), nil
+	}
+
+	switch {
+	case t == timeType:
+		return &Schema{Type: Type{"string"}, Format: "date-time"}, nil
+	case t == rawMessageType:
+		return &Schema{}, nil
+	case t.Implements(jsonMarshalerType):
+		return &Schema{}, nil
+	case t.Implements(textMarshalerType) && reflect.PointerTo(t).Implements(textUnmarshalerType):
+		return &Schema{Type: Type{"string"}}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: Type{"boolean"}}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: Type{"integer"}}, nil
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: Type{"number"}}, nil
+	case reflect.String:
+		return &Schema{Type: Type{"string"}}, nil
+	case reflect.Slice, reflect.Array:
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: Type{"string"}, Format: "byte"}, nil
+		}
+		items, err := g.generate(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Type: Type{"array"}, Items: items}, nil
+	case reflect.Map:
+		switch t.Key().Kind() {
+		case reflect.String, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		default:
+			return nil, fmt.Errorf("schema: unsupported map key type %s", t.Key())
+		}
+		values, err := g.generate(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Type: Type{"object"}, AdditionalProperties: values}, nil
+	case reflect.Struct:
+		return g.generateStruct(t)
+	case reflect.Interface:
+		return &Schema{}, nil
+	}
+	return nil, fmt.Errorf("schema: unsupported type %s", t)
+}
+
+func (g *generator) generateStruct(t reflect.Type) (*Schema, error) {
+	if g.visiting[t] {
+		g.recursive[t] = true
+		return &Schema{Ref: "#/$defs/" + g.defName(t)}, nil
+	}
+	g.visiting[t] = true
+	defer delete(g.visiting, t)
+
+	s := &Schema{
+		Type:                 Type{"object"},
+		Properties:           map[string]*Schema{},
+		AdditionalProperties: false,
+	}
+	if err := g.addFields(s, t); err != nil {
+		return nil, err
+	}
+
+	if g.recursive[t] {
+		name := g.defName(t)
+		g.defs[name] = s
+		return &Schema{Ref: "#/$defs/" + name}, nil
+	}
+	return s, nil
+}
+
+// addFields adds the fields of t to s, promoting the fields of embedded
+// structs the way encoding/json does.
+func (g *generator) addFields(s *Schema, t reflect.Type) error {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, omitempty, skip := jsonName(f)
+		if skip {
+			continue
+		}
+
+		ft := f.Type
+		if f.Anonymous && f.Tag.Get("json") == "" {
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				if err := g.addFields(s, ft); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+
+		prop, err := g.generate(ft)
+		if err != nil {
+			return fmt.Errorf("%s.%s: %w", t.Name(), f.Name, err)
+		}
+		tag, err := parseTag(f.Tag.Get("jsonschema"))
+		if err != nil {
+			return fmt.Errorf("schema: %s.%s: %w", t.Name(), f.Name, err)
+		}
+		if prop, err = tag.apply(prop, ft); err != nil {
+			return fmt.Errorf("schema: %s.%s: %w", t.Name(), f.Name, err)
+		}
+
+		if _, dup := s.Properties[name]; !dup {
+			s.Properties[name] = prop
+			required := !omitempty && ft.Kind() != reflect.Pointer
+			if tag.required != nil {
+				required = *tag.required
+			}
+			if required {
+				s.Required = append(s.Required, name)
+			}
+		}
+	}
+	return nil
+}
+
+func (g *generator) defName(t reflect.Type) string {
+	if name, ok := g.names[t]; ok {
+		return name
+	}
+	base := t.Name()
+	if base == "" {
+		base = "Type"
+	}
+	name := base
+	for i := 2; ; i++ {
+		taken := false
+		for _, n := range g.names {
+			if n == name {
+				taken = true
+				break
+			}
+		}
+		if !taken {
+			break
+		}
+		name = base + strconv.Itoa(i)
+	}
+	g.names[t] = name
+	return name
+}
+
+// nullable allows null in addition to the values s accepts.
+func nullable(s *Schema) *Schema {
+	if s.Ref != "" {
+		return &Schema{AnyOf: []*Schema{s, {Type: Type{"null"}}}}
+	}
+	if len(s.Type) > 0 && !s.Type.has("null") {
+		s.Type = append(append(Type(nil), s.Type...), "null")
+	}
+	return s
+}
+
+func jsonName(f reflect.StructField) (name string, omitempty, skip bool) {
+	tag := f.Tag.Get("json")
+	if tag == "-" {
+		return "", false, true
+	}
+	name, opts, _ := strings.Cut(tag, ",")
+	if name == "" {
+		name = f.Name
+	}
+	for _, opt := range strings.Split(opts, ",") {
+		if opt == "omitempty" {
+			omitempty = true
+		}
+	}
+	return name, omitempty, false
+}
+
+type fieldTag struct {
+	description string
+	format      string
+	enum        []string
+	required    *bool
+}
+
+func parseTag(tag string) (fieldTag, error) {
+	var ft fieldTag
+	for _, part := range splitTag(tag) {
+		key, value, _ := strings.Cut(part, "=")
+		switch strings.TrimSpace(key) {
+		case "":
+		case "description":
+			ft.description = value
+		case "format":
+			ft.format = value
+		case "enum":
+			ft.enum = append(ft.enum, value)
+		case "required":
+			b := true
+			ft.required = &b
+		case "optional":
+			b := false
+			ft.required = &b
+		default:
+			return ft, fmt.Errorf("unknown jsonschema tag key %q", key)
+		}
+	}
+	return ft, nil
+}
+
+// splitTag splits a jsonschema tag on commas that are not escaped as \,.
+func splitTag(tag string) []string {
+	var parts []string
+	var cur strings.Builder
+	for i := 0; i < len(tag); i++ {
+		switch {
+		case tag[i] == '\\' && i+1 < len(tag) && tag[i+1] == ',':
+			cur.WriteByte(',')
+			i++
+		case tag[i] == ',':
+			parts = append(parts, cur.String())
+			cur.Reset()
+		default:
+			cur.WriteByte(tag[i])
+		}
+	}
+	return append(parts, cur.String())
+}
+
+func (ft fieldTag) apply(s *Schema, t reflect.Type) (*Schema, error) {
+	if ft.description == "" && ft.format == "" && len(ft.enum) == 0 {
+		return s, nil
+	}
+	if ft.description != "" {
+		s.Description = ft.description
+	}
+	if ft.format != "" {
+		s.Format = ft.format
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	for _, v := range ft.enum {
+		ev, err := enumValue(v, t)
+		if err != nil {
+			return nil, err
+		}
+		s.Enum = append(s.Enum, ev)
+	}
+	if len(ft.enum) > 0 && s.Type.has("null") {
+		s.Enum = append(s.Enum, nil)
+	}
+	return s, nil
+}
+
+func enumValue(v string, t reflect.Type) (interface{}, error) {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.ParseInt(v, 10, 64)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.ParseUint(v, 10, 64)
+	case reflect.Float32, reflect.Float64:
+		return strconv.ParseFloat(v, 64)
+	case reflect.Bool:
+		return strconv.ParseBool(v)
+	}
+	return v, nil
+}
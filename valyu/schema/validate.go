@@ -0,0 +1,253 @@
+package schema
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// FieldError is one schema violation. Path is a JSON Pointer to the value,
+// e.g. "/key_points/2".
+type FieldError struct {
+	Path    string
+	Message string
+}
+
+func (e FieldError) String() string {
+	path := e.Path
+	if path == "" {
+		path = "/"
+	}
+	return path + ": " + e.Message
+}
+
+// ValidationError lists every way a value failed to match its schema.
+type ValidationError struct {
+	Errors []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, fe := range e.Errors {
+		msgs[i] = fe.String()
+	}
+	return "schema: validation failed: " + strings.Join(msgs, "; ")
+}
+
+// Validate checks a value decoded by encoding/json into interface{} against
+// s. It returns a *ValidationError if the value does not match.
+func Validate(s *Schema, v interface{}) error {
+	vd := validator{root: s}
+	vd.validate(s, v, "")
+	if len(vd.errs) > 0 {
+		return &ValidationError{Errors: vd.errs}
+	}
+	return nil
+}
+
+// ValidateJSON decodes data and validates it against s.
+func ValidateJSON(s *Schema, data []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return &ValidationError{Errors: []FieldError{{Message: "invalid JSON: " + err.Error()}}}
+	}
+	return Validate(s, v)
+}
+
+type validator struct {
+	root  *Schema
+	errs  []FieldError
+	depth int
+}
+
+func (vd *validator) fail(path, format string, args ...interface{}) {
+	vd.errs = append(vd.errs, FieldError{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (vd *validator) validate(s *Schema, v interface{}, path string) {
+	if s == nil {
+		return
+	}
+	if s.Ref != "" {
+		ref, ok := vd.resolve(s.Ref)
+		if !ok {
+			vd.fail(path, "unresolvable reference %q", s.Ref)
+			return
+		}
+		if vd.depth++; vd.depth > 1000 {
+			vd.fail(path, "reference nesting too deep")
+			return
+		}
+		vd.validate(ref, v, path)
+		vd.depth--
+	}
+
+	if len(s.AnyOf) > 0 {
+		var best []FieldError
+		for i, alt := range s.AnyOf {
+			sub := validator{root: vd.root, depth: vd.depth}
+			sub.validate(alt, v, path)
+			if len(sub.errs) == 0 {
+				best = nil
+				break
+			}
+			if i == 0 || len(sub.errs) < len(best) {
+				best = sub.errs
+			}
+		}
+		vd.errs = append(vd.errs, best...)
+	}
+
+	if len(s.Type) > 0 && !matchesType(v, s.Type) {
+		vd.fail(path, "expected %s, got %s", strings.Join(s.Type, " or "), typeOf(v))
+		return
+	}
+	if len(s.Enum) > 0 && !inEnum(v, s.Enum) {
+		vd.fail(path, "value %s is not one of %s", encode(v), encode(s.Enum))
+	}
+
+	switch t := v.(type) {
+	case string:
+		if err := checkFormat(s.Format, t); err != nil {
+			vd.fail(path, "invalid %s: %v", s.Format, err)
+		}
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := t[name]; !ok {
+				vd.fail(path+"/"+escape(name), "required property is missing")
+			}
+		}
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			child := path + "/" + escape(k)
+			if prop, ok := s.Properties[k]; ok {
+				vd.validate(prop, t[k], child)
+				continue
+			}
+			switch ap := s.AdditionalProperties.(type) {
+			case bool:
+				if !ap {
+					vd.fail(child, "unexpected property")
+				}
+			case *Schema:
+				vd.validate(ap, t[k], child)
+			}
+		}
+	case []interface{}:
+		for i, item := range t {
+			vd.validate(s.Items, item, fmt.Sprintf("%s/%d", path, i))
+		}
+	}
+}
+
+func (vd *validator) resolve(ref string) (*Schema, bool) {
+	name, ok := strings.CutPrefix(ref, "#/$defs/")
+	if !ok || vd.root == nil {
+		return nil, false
+	}
+	s, ok := vd.root.Defs[name]
+	return s, ok
+}
+
+func matchesType(v interface{}, types Type) bool {
+	for _, t := range types {
+		if hasType(v, t) {
+			return true
+		}
+	}
+	return false
+}
+
+func inEnum(v interface{}, enum []interface{}) bool {
+	got := encode(v)
+	for _, e := range enum {
+		if encode(e) == got {
+			return true
+		}
+	}
+	return false
+}
+
+func encode(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+func checkFormat(format, s string) error {
+	var err error
+	switch format {
+	case "date-time":
+		_, err = time.Parse(time.RFC3339, s)
+	case "date":
+		_, err = time.Parse(time.DateOnly, s)
+	case "byte":
+		_, err = base64.StdEncoding.DecodeString(s)
+	case "uri":
+		var u *url.URL
+		if u, err = url.Parse(s); err == nil && !u.IsAbs() {
+			err = errors.New("not an absolute URI")
+		}
+	}
+	return err
+}
+
+func hasType(v interface{}, typ string) bool {
+	switch typ {
+	case "object":
+		_, ok := v.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := v.([]interface{})
+		return ok
+	case "string":
+		_, ok := v.(string)
+		return ok
+	case "boolean":
+		_, ok := v.(bool)
+		return ok
+	case "number":
+		_, ok := v.(float64)
+		return ok
+	case "integer":
+		f, ok := v.(float64)
+		return ok && f == math.Trunc(f)
+	case "null":
+		return v == nil
+	}
+	return true
+}
+
+func typeOf(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func escape(s string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(s)
+}
@@ -0,0 +1,127 @@
+package schema
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type testNode struct {
+	Name     string      `json:"name"`
+	Children []*testNode `json:"children,omitempty"`
+}
+
+type testPaper struct {
+	Title    string            `json:"title" jsonschema:"description=Paper title"`
+	Status   string            `json:"status" jsonschema:"enum=draft,enum=published"`
+	Year     int               `json:"year"`
+	Score    float64           `json:"score,omitempty"`
+	DOI      *string           `json:"doi,omitempty"`
+	URL      string            `json:"url,omitempty" jsonschema:"format=uri"`
+	Date     time.Time         `json:"date,omitempty"`
+	Tags     []string          `json:"tags,omitempty"`
+	Extra    map[string]int    `json:"extra,omitempty"`
+	Outline  *testNode         `json:"outline,omitempty"`
+	Raw      []byte            `json:"raw,omitempty"`
+	Meta     map[string]string `json:"a/b~c,omitempty"`
+	internal string
+}
+
+func TestValidate(t *testing.T) {
+	s := MustFor[testPaper]()
+	valid := `{"title":"T","status":"draft","year":2017}`
+	tests := []struct {
+		name      string
+		doc       string
+		wantPaths []string
+	}{
+		{"minimal", valid, nil},
+		{
+			"every field",
+			`{"title":"T","status":"published","year":2017,"score":1.5,"doi":null,"url":"https://arxiv.org/abs/1",
+			  "date":"2017-06-12T00:00:00Z","tags":["a"],"extra":{"k":1},"raw":"aGk=","a/b~c":{"x":"y"},
+			  "outline":{"name":"root","children":[{"name":"leaf"}]}}`,
+			nil,
+		},
+		{"not an object", `[]`, []string{""}},
+		{"missing required", `{"title":"T"}`, []string{"/status", "/year"}},
+		{"wrong type", `{"title":1,"status":"draft","year":2017}`, []string{"/title"}},
+		{"not an integer", `{"title":"T","status":"draft","year":2017.5}`, []string{"/year"}},
+		{"not in enum", `{"title":"T","status":"retracted","year":2017}`, []string{"/status"}},
+		{"unexpected property", `{"title":"T","status":"draft","year":2017,"internal":"x"}`, []string{"/internal"}},
+		{"bad uri", `{"title":"T","status":"draft","year":2017,"url":"/relative"}`, []string{"/url"}},
+		{"bad date-time", `{"title":"T","status":"draft","year":2017,"date":"yesterday"}`, []string{"/date"}},
+		{"bad base64", `{"title":"T","status":"draft","year":2017,"raw":"!!"}`, []string{"/raw"}},
+		{"array item", `{"title":"T","status":"draft","year":2017,"tags":["a",2]}`, []string{"/tags/1"}},
+		{"map value", `{"title":"T","status":"draft","year":2017,"extra":{"k":"v"}}`, []string{"/extra/k"}},
+		{"escaped key", `{"title":"T","status":"draft","year":2017,"a/b~c":{"x":1}}`, []string{"/a~1b~0c/x"}},
+		{
+			"recursive reference",
+			`{"title":"T","status":"draft","year":2017,"outline":{"name":"root","children":[{"children":[]}]}}`,
+			[]string{"/outline/children/0/name"},
+		},
+		{"nullable pointer", `{"title":"T","status":"draft","year":2017,"outline":null}`, nil},
+		{"invalid JSON", `{"title":`, []string{""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateJSON(s, []byte(tt.doc))
+			if tt.wantPaths == nil {
+				if err != nil {
+					t.Fatalf("ValidateJSON = %v, want nil", err)
+				}
+				return
+			}
+			var verr *ValidationError
+			if !errors.As(err, &verr) {
+				t.Fatalf("ValidateJSON = %v, want a *ValidationError", err)
+			}
+			var paths []string
+			for _, fe := range verr.Errors {
+				paths = append(paths, fe.Path)
+			}
+			if !reflect.DeepEqual(paths, tt.wantPaths) {
+				t.Errorf("error paths = %q, want %q (%v)", paths, tt.wantPaths, err)
+			}
+		})
+	}
+}
+
+func TestValidateKeywords(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema *Schema
+		value  interface{}
+		wantOK bool
+	}{
+		{"empty schema accepts anything", &Schema{}, map[string]interface{}{"x": 1.0}, true},
+		{"type list", &Schema{Type: Type{"string", "null"}}, nil, true},
+		{"type list rejects", &Schema{Type: Type{"string", "null"}}, 1.0, false},
+		{"enum of numbers", &Schema{Enum: []interface{}{1, 2}}, 2.0, true},
+		{"enum of objects", &Schema{Enum: []interface{}{map[string]interface{}{"a": 1}}}, map[string]interface{}{"a": 1.0}, true},
+		{"anyOf first", &Schema{AnyOf: []*Schema{{Type: Type{"string"}}, {Type: Type{"number"}}}}, "x", true},
+		{"anyOf second", &Schema{AnyOf: []*Schema{{Type: Type{"string"}}, {Type: Type{"number"}}}}, 1.0, true},
+		{"anyOf none", &Schema{AnyOf: []*Schema{{Type: Type{"string"}}, {Type: Type{"number"}}}}, true, false},
+		{"date format", &Schema{Type: Type{"string"}, Format: "date"}, "2024-02-30", false},
+		{"unknown format ignored", &Schema{Type: Type{"string"}, Format: "email"}, "not an email", true},
+		{"additional properties schema", &Schema{Type: Type{"object"}, AdditionalProperties: &Schema{Type: Type{"number"}}}, map[string]interface{}{"a": "x"}, false},
+		{"unresolvable ref", &Schema{Ref: "#/$defs/Missing"}, 1.0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Validate(tt.schema, tt.value); (err == nil) != tt.wantOK {
+				t.Errorf("Validate = %v, want ok %v", err, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestSelfReferentialRef(t *testing.T) {
+	s := &Schema{Ref: "#/$defs/Loop", Defs: map[string]*Schema{"Loop": {Ref: "#/$defs/Loop"}}}
+	err := Validate(s, 1.0)
+	var verr *ValidationError
+	if !errors.As(err, &verr) || verr.Errors[0].Message != "reference nesting too deep" {
+		t.Errorf("Validate = %v, want a nesting error", err)
+	}
+}
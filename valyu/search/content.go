@@ -0,0 +1,27 @@
+package search
+
+import "github.com/Veri5ied/valyu-go/valyu/datatype"
+
+// Text returns the content as text; structured content is rendered as
+// compact JSON.
+func (r Result) Text() string {
+	return datatype.Text(r.Content)
+}
+
+// JSON decodes the content into v, e.g. a pointer to a struct or slice
+// matching the source's response schema.
+func (r Result) JSON(v interface{}) error {
+	return datatype.JSON(r.Content, v)
+}
+
+// Rows returns structured content as a list of JSON objects, or
+// datatype.ErrNotRows.
+func (r Result) Rows() ([]map[string]interface{}, error) {
+	return datatype.Rows(r.Content)
+}
+
+// Decode decodes the content into the type registered in
+// datatype.Default for the result's source or data type.
+func (r Result) Decode() (interface{}, error) {
+	return datatype.Default.Decode(r.Source, r.DataType, r.Content)
+}
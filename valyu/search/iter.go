@@ -0,0 +1,318 @@
+package search
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Veri5ied/valyu-go/valyu/option"
+)
+
+// MaxPageSize is the most results the API returns for one request;
+// iterators clamp Options.MaxNumResults to it.
+const MaxPageSize = 20
+
+// DefaultPageSize and DefaultMaxPages apply when Options.MaxNumResults and
+// IterOptions.MaxPages are unset; DefaultWindowDays is the window size of
+// DateWindows(0).
+const (
+	DefaultPageSize   = 20
+	DefaultMaxPages   = 10
+	DefaultWindowDays = 90
+)
+
+// IterOptions bounds an iteration started by Iterate, SearchIter or
+// SearchAll. Whichever limit is reached first ends it.
+type IterOptions struct {
+	// MaxResults stops after this many unique results. Zero means no
+	// limit other than MaxPages.
+	MaxResults int
+	// MinRelevance skips results scoring below it and stops once a page
+	// adds nothing at or above it. It is also sent as the request's
+	// RelevanceThreshold.
+	MinRelevance float64
+	// MaxSpend is the dollar budget of the whole iteration. Each request's
+	// MaxPrice is lowered so the page cannot cost more than what is left,
+	// and iteration stops once the budget is used up.
+	MaxSpend float64
+	// MaxPages caps the number of requests. Zero means DefaultMaxPages.
+	MaxPages int
+	// Pager decides the options of each page. Nil means a single page
+	// sent exactly as Search would send it; set DateWindows or SourcePages
+	// to fetch more.
+	Pager Pager
+}
+
+// A Pager returns the options for page n, counting from zero, given the
+// options passed to the iterator and the response to page n-1 (nil for the
+// first page). It reports false when there are no more pages.
+//
+// The search API has no offset or cursor, so pagers move through results
+// by narrowing each request to a part of the corpus the others do not
+// cover, so no page pays for results already fetched.
+type Pager func(n int, base Options, prev *Response) (Options, bool)
+
+// DateWindows searches consecutive windows of the given number of days,
+// walking back in time from base.EndDate (or today) and stopping at
+// base.StartDate, if set. Each page asks for the same number of results.
+// Zero days means DefaultWindowDays.
+func DateWindows(days int) Pager {
+	if days < 1 {
+		days = DefaultWindowDays
+	}
+	return func(n int, base Options, prev *Response) (Options, bool) {
+		end := time.Now().UTC()
+		if t, err := time.Parse(dateLayout, base.EndDate); err == nil {
+			end = t
+		}
+		end = end.AddDate(0, 0, -n*days)
+		start := end.AddDate(0, 0, 1-days)
+
+		if floor, err := time.Parse(dateLayout, base.StartDate); err == nil {
+			if end.Before(floor) {
+				return base, false
+			}
+			if start.Before(floor) {
+				start = floor
+			}
+		}
+		base.StartDate = start.Format(dateLayout)
+		base.EndDate = end.Format(dateLayout)
+		return base, true
+	}
+}
+
+const dateLayout = "2006-01-02"
+
+// firstPage is the default Pager: the options as given, once.
+func firstPage(n int, base Options, prev *Response) (Options, bool) {
+	return base, n == 0
+}
+
+// SourcePages searches one source per page, in order, and stops after the
+// last. With no sources it rotates through base.IncludedSources.
+func SourcePages(sources ...string) Pager {
+	return func(n int, base Options, prev *Response) (Options, bool) {
+		list := sources
+		if len(list) == 0 {
+			list = base.IncludedSources
+		}
+		if n >= len(list) {
+			return base, false
+		}
+		base.IncludedSources = []string{list[n]}
+		return base, true
+	}
+}
+
+// Iterator pages through search results one at a time:
+//
+//	it := client.Search.Iterate(ctx, query, nil, &search.IterOptions{
+//		MaxResults: 200,
+//		Pager:      search.DateWindows(0),
+//	})
+//	for it.Next() {
+//		r := it.Result()
+//		...
+//	}
+//	if err := it.Err(); err != nil { ... }
+//
+// Results are de-duplicated across pages by ID, or by URL when they have
+// no ID.
+type Iterator struct {
+	ctx     context.Context
+	s       *Service
+	query   string
+	base    Options
+	cfg     IterOptions
+	reqOpts []option.RequestOption
+
+	page   int
+	prev   *Response
+	buf    []Result
+	seen   map[string]struct{}
+	count  int
+	spent  float64
+	result Result
+	err    error
+	done   bool
+}
+
+// Iterate returns an Iterator over the results of query. No request is
+// made until the first call to Next.
+func (s *Service) Iterate(ctx context.Context, query string, opts *Options, iterOpts *IterOptions, reqOpts ...option.RequestOption) *Iterator {
+	it := &Iterator{
+		ctx:     ctx,
+		s:       s,
+		query:   query,
+		reqOpts: reqOpts,
+		seen:    make(map[string]struct{}),
+	}
+	if opts != nil {
+		it.base = *opts
+	}
+	if iterOpts != nil {
+		it.cfg = *iterOpts
+	}
+	if it.cfg.MaxPages == 0 {
+		it.cfg.MaxPages = DefaultMaxPages
+	}
+	if it.cfg.MinRelevance > it.base.RelevanceThreshold {
+		it.base.RelevanceThreshold = it.cfg.MinRelevance
+	}
+	if it.cfg.Pager == nil {
+		it.cfg.Pager = firstPage
+	}
+	if it.base.MaxNumResults <= 0 {
+		it.base.MaxNumResults = DefaultPageSize
+	}
+	it.base.MaxNumResults = min(it.base.MaxNumResults, MaxPageSize)
+	return it
+}
+
+// Next advances to the next unique result, fetching pages as needed. It
+// returns false when a limit is reached, the pages run out or a request
+// fails; check Err to tell them apart.
+func (it *Iterator) Next() bool {
+	if it.done {
+		return false
+	}
+	if it.cfg.MaxResults > 0 && it.count >= it.cfg.MaxResults {
+		it.done = true
+		return false
+	}
+	for len(it.buf) == 0 {
+		if !it.fetch() {
+			it.done = true
+			return false
+		}
+	}
+	it.result, it.buf = it.buf[0], it.buf[1:]
+	it.count++
+	return true
+}
+
+// fetch loads the next page into buf. It reports false when iteration is
+// over.
+func (it *Iterator) fetch() bool {
+	if it.page >= it.cfg.MaxPages {
+		return false
+	}
+	if it.cfg.MaxSpend > 0 && it.spent >= it.cfg.MaxSpend {
+		return false
+	}
+	opts, ok := it.cfg.Pager(it.page, it.base, it.prev)
+	if !ok {
+		return false
+	}
+	if opts.MaxNumResults <= 0 || opts.MaxNumResults > MaxPageSize {
+		opts.MaxNumResults = it.base.MaxNumResults
+	}
+	if it.cfg.MaxSpend > 0 {
+		// MaxPrice is per thousand results, so this caps the page at the
+		// remaining budget even if every result is charged.
+		cpm := (it.cfg.MaxSpend - it.spent) * 1000 / float64(opts.MaxNumResults)
+		if opts.MaxPrice == 0 || cpm < opts.MaxPrice {
+			opts.MaxPrice = cpm
+		}
+	}
+	resp, err := it.s.Search(it.ctx, it.query, &opts, it.reqOpts...)
+	if err != nil {
+		it.err = err
+		return false
+	}
+	it.page++
+	it.prev = resp
+	it.spent += resp.TotalDeductionDollars
+
+	fresh, relevant := 0, 0
+	for _, r := range resp.Results {
+		key := dedupKey(r)
+		if key != "" {
+			if _, dup := it.seen[key]; dup {
+				continue
+			}
+			it.seen[key] = struct{}{}
+		}
+		fresh++
+		if it.cfg.MinRelevance > 0 && r.RelevanceScore < it.cfg.MinRelevance {
+			continue
+		}
+		relevant++
+		it.buf = append(it.buf, r)
+	}
+	if it.cfg.MinRelevance > 0 && fresh > 0 && relevant == 0 {
+		return false
+	}
+	return true
+}
+
+// Result returns the current result.
+func (it *Iterator) Result() Result {
+	return it.result
+}
+
+// Err returns the error that ended the iteration, if any.
+func (it *Iterator) Err() error {
+	return it.err
+}
+
+// Spent returns the dollars charged for the pages fetched so far.
+func (it *Iterator) Spent() float64 {
+	return it.spent
+}
+
+// Pages returns the number of pages fetched so far.
+func (it *Iterator) Pages() int {
+	return it.page
+}
+
+// SearchAll collects the results of Iterate. On error it returns the
+// results gathered before the failing request along with the error.
+func (s *Service) SearchAll(ctx context.Context, query string, opts *Options, iterOpts *IterOptions, reqOpts ...option.RequestOption) ([]Result, error) {
+	var results []Result
+	it := s.Iterate(ctx, query, opts, iterOpts, reqOpts...)
+	for it.Next() {
+		results = append(results, it.Result())
+	}
+	return results, it.Err()
+}
+
+func (s *Service) seq(ctx context.Context, query string, opts *Options, iterOpts *IterOptions, reqOpts []option.RequestOption) func(yield func(Result, error) bool) {
+	return func(yield func(Result, error) bool) {
+		it := s.Iterate(ctx, query, opts, iterOpts, reqOpts...)
+		for it.Next() {
+			if !yield(it.Result(), nil) {
+				return
+			}
+		}
+		if err := it.Err(); err != nil {
+			yield(Result{}, err)
+		}
+	}
+}
+
+// dedupKey identifies a result across pages: its ID, or else its URL
+// without scheme, "www.", fragment or trailing slash.
+func dedupKey(r Result) string {
+	if r.ID != "" {
+		return "id:" + r.ID
+	}
+	if r.URL == "" {
+		return ""
+	}
+	u, err := url.Parse(r.URL)
+	if err != nil || u.Host == "" {
+		return "url:" + r.URL
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Host), "www.")
+	return "url:" + host + strings.TrimSuffix(u.EscapedPath(), "/") + queryPart(u)
+}
+
+func queryPart(u *url.URL) string {
+	if u.RawQuery == "" {
+		return ""
+	}
+	return "?" + u.RawQuery
+}
@@ -0,0 +1,302 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/Veri5ied/valyu-go/valyu/internal/api"
+)
+
+// fakeSearch serves /search, answering each request with page(n, req) for
+// the n-th request and recording the requests.
+type fakeSearch struct {
+	mu   sync.Mutex
+	reqs []map[string]interface{}
+	page func(n int, req map[string]interface{}) Response
+}
+
+func (f *fakeSearch) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	f.mu.Lock()
+	n := len(f.reqs)
+	f.reqs = append(f.reqs, req)
+	f.mu.Unlock()
+	resp := f.page(n, req)
+	resp.Success = true
+	json.NewEncoder(w).Encode(resp)
+}
+
+func newTestService(t *testing.T, f *fakeSearch) *Service {
+	t.Helper()
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	return New(api.New(srv.URL, "test-key", srv.Client()))
+}
+
+// results returns results with the given URLs, scored from 1 down.
+func results(urls ...string) []Result {
+	out := make([]Result, len(urls))
+	for i, u := range urls {
+		out[i] = Result{Title: u, URL: u, RelevanceScore: 1 - float64(i)/100}
+	}
+	return out
+}
+
+func urls(rs []Result) []string {
+	var out []string
+	for _, r := range rs {
+		out = append(out, r.URL)
+	}
+	return out
+}
+
+func TestSearchAll(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     *Options
+		iter     *IterOptions
+		pages    [][]Result
+		cost     float64
+		want     []string
+		wantReqs int
+	}{
+		{
+			name: "dedups across pages",
+			iter: &IterOptions{MaxPages: 3, Pager: DateWindows(0)},
+			pages: [][]Result{
+				results("https://a.com/1", "https://b.com/2"),
+				results("https://www.a.com/1/", "https://a.com/1#top", "https://c.com/3"),
+				results("https://b.com/2", "not a url"),
+			},
+			want:     []string{"https://a.com/1", "https://b.com/2", "https://c.com/3", "not a url"},
+			wantReqs: 3,
+		},
+		{
+			name: "stops at max results",
+			iter: &IterOptions{MaxResults: 3, Pager: DateWindows(0)},
+			pages: [][]Result{
+				results("https://a.com/1", "https://a.com/2"),
+				results("https://a.com/3", "https://a.com/4"),
+				results("https://a.com/5"),
+			},
+			want:     []string{"https://a.com/1", "https://a.com/2", "https://a.com/3"},
+			wantReqs: 2,
+		},
+		{
+			name: "stops at relevance floor",
+			iter: &IterOptions{MinRelevance: 0.995, Pager: DateWindows(0)},
+			pages: [][]Result{
+				results("https://a.com/1", "https://a.com/2"),
+				results("https://a.com/3"),
+				results("https://a.com/4", "https://a.com/5"),
+				{{URL: "https://a.com/6", RelevanceScore: 0.5}},
+				results("https://a.com/7"),
+			},
+			want:     []string{"https://a.com/1", "https://a.com/3", "https://a.com/4"},
+			wantReqs: 4,
+		},
+		{
+			name: "stops at spend budget",
+			iter: &IterOptions{MaxSpend: 0.25, Pager: DateWindows(0)},
+			pages: [][]Result{
+				results("https://a.com/1"),
+				results("https://a.com/2"),
+				results("https://a.com/3"),
+				results("https://a.com/4"),
+			},
+			cost:     0.1,
+			want:     []string{"https://a.com/1", "https://a.com/2", "https://a.com/3"},
+			wantReqs: 3,
+		},
+		{
+			name:     "stops at max pages",
+			iter:     &IterOptions{MaxPages: 2, Pager: DateWindows(0)},
+			pages:    [][]Result{results("https://a.com/1"), results("https://a.com/2"), results("https://a.com/3")},
+			want:     []string{"https://a.com/1", "https://a.com/2"},
+			wantReqs: 2,
+		},
+		{
+			name:     "empty pages continue",
+			iter:     &IterOptions{MaxPages: 3, Pager: DateWindows(0)},
+			pages:    [][]Result{nil, results("https://a.com/1"), nil},
+			want:     []string{"https://a.com/1"},
+			wantReqs: 3,
+		},
+		{
+			name:     "default is a single page",
+			pages:    [][]Result{results("https://a.com/1"), results("https://a.com/2")},
+			want:     []string{"https://a.com/1"},
+			wantReqs: 1,
+		},
+		{
+			name:     "source pages end after last source",
+			opts:     &Options{IncludedSources: []string{"valyu/a", "valyu/b"}},
+			iter:     &IterOptions{Pager: SourcePages()},
+			pages:    [][]Result{results("https://a.com/1"), results("https://b.com/1"), results("https://c.com/1")},
+			want:     []string{"https://a.com/1", "https://b.com/1"},
+			wantReqs: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeSearch{page: func(n int, req map[string]interface{}) Response {
+				if n >= len(tt.pages) {
+					return Response{}
+				}
+				return Response{Results: tt.pages[n], TotalDeductionDollars: tt.cost}
+			}}
+			s := newTestService(t, f)
+
+			got, err := s.SearchAll(context.Background(), "q", tt.opts, tt.iter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(urls(got), tt.want) {
+				t.Errorf("results = %q, want %q", urls(got), tt.want)
+			}
+			if len(f.reqs) != tt.wantReqs {
+				t.Errorf("made %d requests, want %d", len(f.reqs), tt.wantReqs)
+			}
+		})
+	}
+}
+
+func TestIteratorRequests(t *testing.T) {
+	f := &fakeSearch{page: func(n int, req map[string]interface{}) Response {
+		return Response{Results: results(fmt.Sprintf("https://a.com/%d", n)), TotalDeductionDollars: 0.01}
+	}}
+	s := newTestService(t, f)
+
+	it := s.Iterate(context.Background(), "q", &Options{
+		MaxNumResults: 50,
+		MaxPrice:      100,
+		StartDate:     "2024-01-01",
+		EndDate:       "2024-03-31",
+	}, &IterOptions{MaxSpend: 0.02, MinRelevance: 0.5, Pager: DateWindows(DefaultWindowDays)})
+	for it.Next() {
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(f.reqs) != 2 {
+		t.Fatalf("made %d requests, want 2", len(f.reqs))
+	}
+	for i, want := range []struct {
+		start, end string
+		maxPrice   float64
+	}{
+		{"2024-01-02", "2024-03-31", 1},   // (0.02 budget * 1000) / 20 results
+		{"2024-01-01", "2024-01-01", 0.5}, // 0.01 left
+	} {
+		req := f.reqs[i]
+		if got := req["max_num_results"]; got != float64(MaxPageSize) {
+			t.Errorf("request %d: max_num_results = %v, want %d", i, got, MaxPageSize)
+		}
+		if got := req["start_date"]; got != want.start {
+			t.Errorf("request %d: start_date = %v, want %s", i, got, want.start)
+		}
+		if got := req["end_date"]; got != want.end {
+			t.Errorf("request %d: end_date = %v, want %s", i, got, want.end)
+		}
+		if got := req["max_price"].(float64); fmt.Sprintf("%.4f", got) != fmt.Sprintf("%.4f", want.maxPrice) {
+			t.Errorf("request %d: max_price = %v, want %v", i, got, want.maxPrice)
+		}
+		if got := req["relevance_threshold"]; got != 0.5 {
+			t.Errorf("request %d: relevance_threshold = %v, want 0.5", i, got)
+		}
+	}
+	if got := it.Spent(); fmt.Sprintf("%.2f", got) != "0.02" {
+		t.Errorf("Spent() = %v, want 0.02", got)
+	}
+}
+
+func TestDefaultPageMatchesSearch(t *testing.T) {
+	f := &fakeSearch{page: func(n int, req map[string]interface{}) Response {
+		return Response{Results: results("https://a.com/1")}
+	}}
+	s := newTestService(t, f)
+	opts := &Options{MaxNumResults: 5, Category: "ai"}
+
+	if _, err := s.Search(context.Background(), "q", opts); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.SearchAll(context.Background(), "q", opts, &IterOptions{MaxResults: 100}); err != nil {
+		t.Fatal(err)
+	}
+	if len(f.reqs) != 2 {
+		t.Fatalf("made %d requests, want 2", len(f.reqs))
+	}
+	if !reflect.DeepEqual(f.reqs[1], f.reqs[0]) {
+		t.Errorf("SearchAll sent %v, want the same request as Search: %v", f.reqs[1], f.reqs[0])
+	}
+}
+
+func TestDateWindows(t *testing.T) {
+	tests := []struct {
+		name       string
+		days       int
+		start, end string
+		want       [][2]string
+	}{
+		{
+			name:  "clamps last window to start date",
+			days:  30,
+			start: "2024-01-01",
+			end:   "2024-03-15",
+			want:  [][2]string{{"2024-02-15", "2024-03-15"}, {"2024-01-16", "2024-02-14"}, {"2024-01-01", "2024-01-15"}},
+		},
+		{
+			name:  "single day windows",
+			days:  1,
+			start: "2024-01-01",
+			end:   "2024-01-02",
+			want:  [][2]string{{"2024-01-02", "2024-01-02"}, {"2024-01-01", "2024-01-01"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pager := DateWindows(tt.days)
+			var got [][2]string
+			for n := 0; ; n++ {
+				opts, ok := pager(n, Options{StartDate: tt.start, EndDate: tt.end}, nil)
+				if !ok {
+					break
+				}
+				got = append(got, [2]string{opts.StartDate, opts.EndDate})
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("windows = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDedupKey(t *testing.T) {
+	tests := []struct {
+		r    Result
+		want string
+	}{
+		{Result{ID: "doc-1", URL: "https://a.com"}, "id:doc-1"},
+		{Result{URL: "https://WWW.A.com/x/?q=1#frag"}, "url:a.com/x?q=1"},
+		{Result{URL: "https://a.com/x"}, "url:a.com/x"},
+		{Result{URL: "id:doc-1"}, "url:id:doc-1"},
+		{Result{URL: "%zz"}, "url:%zz"},
+		{Result{}, ""},
+	}
+	for _, tt := range tests {
+		if got := dedupKey(tt.r); got != tt.want {
+			t.Errorf("dedupKey(%+v) = %q, want %q", tt.r, got, tt.want)
+		}
+	}
+}
@@ -20,6 +20,13 @@ type Options struct {
 	URLOnly            bool                  `json:"url_only,omitempty"`
 }
 
+// Request is the body Search sends to /search. Middleware sees it as
+// Request.Body.
+type Request struct {
+	Query string `json:"query"`
+	*Options
+}
+
 type Result struct {
 	Title           string            `json:"title"`
 	URL             string            `json:"url"`
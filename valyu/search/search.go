@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/Veri5ied/valyu-go/valyu/internal/api"
+	"github.com/Veri5ied/valyu-go/valyu/option"
 )
 
 type Service struct {
@@ -14,17 +15,14 @@ func New(client *api.Client) *Service {
 	return &Service{client: client}
 }
 
-func (s *Service) Search(ctx context.Context, query string, opts *Options) (*Response, error) {
-	req := struct {
-		Query string `json:"query"`
-		*Options
-	}{
+func (s *Service) Search(ctx context.Context, query string, opts *Options, reqOpts ...option.RequestOption) (*Response, error) {
+	req := Request{
 		Query:   query,
 		Options: opts,
 	}
 
 	var resp Response
-	if err := s.client.Post(ctx, "/search", req, &resp); err != nil {
+	if err := s.client.Post(ctx, "/search", req, &resp, reqOpts...); err != nil {
 		return nil, err
 	}
 
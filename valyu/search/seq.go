@@ -0,0 +1,25 @@
+//go:build go1.23
+
+package search
+
+import (
+	"context"
+	"iter"
+
+	"github.com/Veri5ied/valyu-go/valyu/option"
+)
+
+// SearchIter returns the results of Iterate as an iterator for range:
+//
+//	for r, err := range client.Search.SearchIter(ctx, query, nil, &search.IterOptions{
+//		MaxResults: 200,
+//		Pager:      search.DateWindows(0),
+//	}) {
+//		if err != nil { ... }
+//		...
+//	}
+//
+// A request error is yielded once, as the last element.
+func (s *Service) SearchIter(ctx context.Context, query string, opts *Options, iterOpts *IterOptions, reqOpts ...option.RequestOption) iter.Seq2[Result, error] {
+	return s.seq(ctx, query, opts, iterOpts, reqOpts)
+}
@@ -0,0 +1,17 @@
+//go:build !go1.23
+
+package search
+
+import (
+	"context"
+
+	"github.com/Veri5ied/valyu-go/valyu/option"
+)
+
+// SearchIter returns the results of Iterate as a push iterator with the
+// shape of Go 1.23's iter.Seq2[Result, error]. Before Go 1.23 it is called
+// with a yield function that returns false to stop early. A request error
+// is yielded once, as the last element.
+func (s *Service) SearchIter(ctx context.Context, query string, opts *Options, iterOpts *IterOptions, reqOpts ...option.RequestOption) func(yield func(Result, error) bool) {
+	return s.seq(ctx, query, opts, iterOpts, reqOpts)
+}
@@ -0,0 +1,564 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/Veri5ied/valyu-go/valyu"
+	"github.com/Veri5ied/valyu-go/valyu/answer"
+	"github.com/Veri5ied/valyu-go/valyu/common"
+	"github.com/Veri5ied/valyu-go/valyu/contents"
+	"github.com/Veri5ied/valyu-go/valyu/deepresearch"
+	"github.com/Veri5ied/valyu-go/valyu/option"
+	"github.com/Veri5ied/valyu-go/valyu/schema"
+	"github.com/Veri5ied/valyu-go/valyu/search"
+)
+
+// DefaultMaxTokens is the default size budget of a tool result.
+const DefaultMaxTokens = 2000
+
+// charsPerToken is a rough conversion used to size results without a
+// tokenizer.
+const charsPerToken = 4
+
+// Call is a tool call made by a model.
+type Call struct {
+	ID        string
+	Name      string
+	Arguments json.RawMessage
+}
+
+// Result is the outcome of a Call, ready to be sent back to the model. If
+// IsError is set, Content describes the failure.
+type Result struct {
+	CallID  string
+	Name    string
+	Content string
+	IsError bool
+	// Cost is the dollar cost the API reported for the call.
+	Cost float64
+}
+
+// ParseCall decodes a tool call in either OpenAI format
+// ({"id", "function": {"name", "arguments": "<json>"}}) or Anthropic format
+// ({"type": "tool_use", "id", "name", "input": {...}}).
+func ParseCall(data []byte) (Call, error) {
+	var raw struct {
+		ID       string          `json:"id"`
+		Name     string          `json:"name"`
+		Input    json.RawMessage `json:"input"`
+		Function *struct {
+			Name      string          `json:"name"`
+			Arguments json.RawMessage `json:"arguments"`
+		} `json:"function"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return Call{}, fmt.Errorf("tools: invalid tool call: %w", err)
+	}
+
+	call := Call{ID: raw.ID, Name: raw.Name, Arguments: raw.Input}
+	if raw.Function != nil {
+		call.Name = raw.Function.Name
+		call.Arguments = raw.Function.Arguments
+		// OpenAI sends the arguments as a JSON-encoded string.
+		var s string
+		if json.Unmarshal(call.Arguments, &s) == nil {
+			call.Arguments = json.RawMessage(s)
+		}
+	}
+	if call.Name == "" {
+		return Call{}, errors.New("tools: tool call has no name")
+	}
+	if len(call.Arguments) == 0 {
+		call.Arguments = json.RawMessage("{}")
+	}
+	return call, nil
+}
+
+type config struct {
+	maxTokens      int
+	reqOpts        []option.RequestOption
+	allowedSources []string
+	spendLimit     float64
+	maxCallCost    float64
+	budgets        []*Budget
+}
+
+type Option func(*config)
+
+// WithMaxTokens sets the approximate size budget of each result.
+func WithMaxTokens(n int) Option {
+	return func(c *config) {
+		c.maxTokens = n
+	}
+}
+
+// WithRequestOptions applies per-call options to every request the
+// dispatcher makes.
+func WithRequestOptions(opts ...option.RequestOption) Option {
+	return func(c *config) {
+		c.reqOpts = append(c.reqOpts, opts...)
+	}
+}
+
+// WithAllowedSources restricts searches, answers and deep research to the
+// given sources (domains, URL prefixes or dataset IDs), which are used when
+// a call names none. Calls naming other sources fail, and contents may only
+// be fetched from allowed sources. A domain also allows its subdomains and
+// paths, both for sources and for content URLs.
+func WithAllowedSources(sources ...string) Option {
+	return func(c *config) {
+		c.allowedSources = append(c.allowedSources, sources...)
+	}
+}
+
+// WithSpendLimit caps the dollars the dispatcher spends, as reported by the
+// API. Before it runs, each call reserves what is left of the limit, or
+// WithMaxCallCost if that is less, and passes it to the API as the call's
+// price ceiling; the reservation is settled to the reported cost when the
+// call returns. Calls that find nothing left to reserve fail, so concurrent
+// calls cannot overshoot together. Answer model usage and deep research
+// have no price ceiling and may still cost more than they reserved.
+func WithSpendLimit(dollars float64) Option {
+	return func(c *config) {
+		c.spendLimit = dollars
+	}
+}
+
+// WithMaxCallCost bounds what a single call may reserve under
+// WithSpendLimit or WithBudget, so that several calls can run at once.
+// Without it one call reserves the whole remaining budget and concurrent
+// calls fail until it returns.
+func WithMaxCallCost(dollars float64) Option {
+	return func(c *config) {
+		c.maxCallCost = dollars
+	}
+}
+
+// WithBudget also charges the dispatcher's calls to b, which other
+// dispatchers may share. Calls reserve from b the same way they do under
+// WithSpendLimit, and the price ceiling is the smaller of the two.
+func WithBudget(b *Budget) Option {
+	return func(c *config) {
+		c.budgets = append(c.budgets, b)
+	}
+}
+
+// Budget is a spend limit shared by several dispatchers, e.g. a cap on a
+// whole server whose sessions each have a dispatcher. It is safe for
+// concurrent use.
+type Budget struct {
+	limit float64
+
+	mu       sync.Mutex
+	spent    float64
+	reserved float64
+}
+
+// NewBudget returns a budget of the given dollars. A budget of 0 or less
+// only tracks spending.
+func NewBudget(dollars float64) *Budget {
+	return &Budget{limit: dollars}
+}
+
+// Limit returns the budget's dollars.
+func (b *Budget) Limit() float64 {
+	return b.limit
+}
+
+// Spent returns the dollars charged to the budget so far.
+func (b *Budget) Spent() float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.spent
+}
+
+// reserve sets aside what is left of the budget, or max if that is less
+// and not 0, and returns it. The caller must release it.
+func (b *Budget) reserve(max float64) (float64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	left := b.limit - b.spent - b.reserved
+	if left <= 0 {
+		if b.reserved > 0 {
+			return 0, fmt.Errorf("spend limit of $%.2f is reserved by calls in progress ($%.4f spent)", b.limit, b.spent)
+		}
+		return 0, fmt.Errorf("spend limit of $%.2f reached ($%.4f spent)", b.limit, b.spent)
+	}
+	if max > 0 && max < left {
+		left = max
+	}
+	b.reserved += left
+	return left, nil
+}
+
+func (b *Budget) release(dollars float64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.reserved -= dollars
+}
+
+func (b *Budget) charge(dollars float64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.spent += dollars
+}
+
+// Dispatcher runs tool calls against a valyu.Client. It is safe for
+// concurrent use; spending is tracked per Dispatcher and in any shared
+// Budget.
+type Dispatcher struct {
+	client *valyu.Client
+	cfg    config
+	own    *Budget
+
+	mu      sync.Mutex
+	charged map[string]bool
+}
+
+func NewDispatcher(client *valyu.Client, opts ...Option) *Dispatcher {
+	cfg := config{maxTokens: DefaultMaxTokens}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return &Dispatcher{
+		client:  client,
+		cfg:     cfg,
+		own:     NewBudget(cfg.spendLimit),
+		charged: map[string]bool{},
+	}
+}
+
+// Tools returns the definitions of the tools the dispatcher can run.
+func (d *Dispatcher) Tools() []Tool {
+	return All()
+}
+
+// Spent returns the dollars spent through the dispatcher so far.
+func (d *Dispatcher) Spent() float64 {
+	return d.own.Spent()
+}
+
+// Dispatch parses a raw tool call and runs it. Failures of the tool itself,
+// including unknown tool names and API errors, are reported in the Result
+// so the model can react to them; the error is only set if data is not a
+// tool call.
+func (d *Dispatcher) Dispatch(ctx context.Context, data []byte) (Result, error) {
+	call, err := ParseCall(data)
+	if err != nil {
+		return Result{}, err
+	}
+	return d.Do(ctx, call), nil
+}
+
+// Do runs a parsed tool call.
+func (d *Dispatcher) Do(ctx context.Context, call Call) Result {
+	res := Result{CallID: call.ID, Name: call.Name}
+	content, cost, err := d.run(ctx, call.Name, call.Arguments)
+	res.Cost = cost
+	if err != nil {
+		res.Content = "Error: " + err.Error()
+		res.IsError = true
+		return res
+	}
+	res.Content = content
+	return res
+}
+
+// Run executes the named tool with JSON arguments and returns its compact
+// result.
+func (d *Dispatcher) Run(ctx context.Context, name string, args json.RawMessage) (string, error) {
+	content, _, err := d.run(ctx, name, args)
+	return content, err
+}
+
+func (d *Dispatcher) run(ctx context.Context, name string, args json.RawMessage) (string, float64, error) {
+	params, ok := paramSchemas[name]
+	if !ok {
+		return "", 0, fmt.Errorf("unknown tool %q", name)
+	}
+	if err := schema.ValidateJSON(params, args); err != nil {
+		return "", 0, fmt.Errorf("invalid arguments: %w", err)
+	}
+	ceiling, release, err := d.reserve()
+	if err != nil {
+		return "", 0, err
+	}
+	defer release()
+
+	budget := d.cfg.maxTokens * charsPerToken
+	switch name {
+	case Search:
+		var a SearchArgs
+		if err := decodeArgs(args, &a); err != nil {
+			return "", 0, err
+		}
+		if a.Query == "" {
+			return "", 0, errors.New("query is required")
+		}
+		sources, err := d.sources(a.IncludedSources)
+		if err != nil {
+			return "", 0, err
+		}
+		opts := &search.Options{
+			SearchType:         common.SearchType(a.SearchType),
+			MaxNumResults:      a.MaxNumResults,
+			IncludedSources:    sources,
+			ExcludeSources:     a.ExcludedSources,
+			StartDate:          a.StartDate,
+			EndDate:            a.EndDate,
+			RelevanceThreshold: a.RelevanceThreshold,
+			IsToolCall:         boolPtr(true),
+		}
+		if ceiling > 0 {
+			// MaxPrice is per thousand results.
+			n := a.MaxNumResults
+			if n <= 0 {
+				n = search.MaxPageSize
+			}
+			opts.MaxPrice = ceiling * 1000 / float64(n)
+		}
+		resp, err := d.client.Search.Search(ctx, a.Query, opts, d.cfg.reqOpts...)
+		if err != nil {
+			return "", 0, err
+		}
+		cost := d.charge("", resp.TotalDeductionDollars)
+		return FormatSearch(resp, budget), cost, nil
+
+	case Contents:
+		var a ContentsArgs
+		if err := decodeArgs(args, &a); err != nil {
+			return "", 0, err
+		}
+		if len(a.URLs) == 0 {
+			return "", 0, errors.New("urls is required")
+		}
+		for _, u := range a.URLs {
+			if !d.urlAllowed(u) {
+				return "", 0, fmt.Errorf("url %q is not from an allowed source", u)
+			}
+		}
+		opts := &contents.Options{MaxPriceDollars: ceiling}
+		if a.Summary != "" {
+			opts.Summary = a.Summary
+		}
+		resp, err := d.client.Contents.Get(ctx, a.URLs, opts, d.cfg.reqOpts...)
+		if err != nil {
+			return "", 0, err
+		}
+		cost := d.charge("", resp.TotalCostDollars)
+		return FormatContents(resp, budget), cost, nil
+
+	case Answer:
+		var a AnswerArgs
+		if err := decodeArgs(args, &a); err != nil {
+			return "", 0, err
+		}
+		if a.Query == "" {
+			return "", 0, errors.New("query is required")
+		}
+		sources, err := d.sources(a.IncludedSources)
+		if err != nil {
+			return "", 0, err
+		}
+		resp, err := d.client.Answer.Answer(ctx, a.Query, &answer.Options{
+			SearchType:      common.SearchType(a.SearchType),
+			DataMaxPrice:    ceiling,
+			IncludedSources: sources,
+			StartDate:       a.StartDate,
+			EndDate:         a.EndDate,
+		}, d.cfg.reqOpts...)
+		if err != nil {
+			return "", 0, err
+		}
+		cost := d.charge("", resp.Cost.TotalDeductionDollars)
+		return FormatAnswer(resp, budget), cost, nil
+
+	case DeepResearch:
+		var a DeepResearchArgs
+		if err := decodeArgs(args, &a); err != nil {
+			return "", 0, err
+		}
+		if a.DeepResearchID != "" {
+			resp, err := d.client.DeepResearch.Get(ctx, a.DeepResearchID, d.cfg.reqOpts...)
+			if err != nil {
+				return "", 0, err
+			}
+			var cost float64
+			if resp.Status == common.DeepResearchStatusCompleted {
+				cost = resp.Cost
+				if resp.Usage != nil && resp.Usage.TotalCost > 0 {
+					cost = resp.Usage.TotalCost
+				}
+				// Polling a finished task again must not count twice.
+				cost = d.charge(resp.DeepResearchID, cost)
+			}
+			return FormatDeepResearch(resp, budget), cost, nil
+		}
+		if a.Query == "" {
+			return "", 0, errors.New("query or deepresearch_id is required")
+		}
+		opts := &deepresearch.CreateOptions{
+			Query: a.Query,
+			Mode:  common.DeepResearchMode(a.Mode),
+		}
+		if len(d.cfg.allowedSources) > 0 {
+			opts.Search = &deepresearch.SearchConfig{IncludedSources: d.cfg.allowedSources}
+		}
+		resp, err := d.client.DeepResearch.Create(ctx, opts, d.cfg.reqOpts...)
+		if err != nil {
+			return "", 0, err
+		}
+		return compactJSON(map[string]interface{}{
+			"deepresearch_id": resp.DeepResearchID,
+			"status":          resp.Status,
+			"next":            "Call " + DeepResearch + " with this deepresearch_id to check progress.",
+		}), 0, nil
+
+	case Datasources:
+		var a DatasourcesArgs
+		if err := decodeArgs(args, &a); err != nil {
+			return "", 0, err
+		}
+		resp, err := d.client.Datasources.List(ctx, d.cfg.reqOpts...)
+		if err != nil {
+			return "", 0, err
+		}
+		return FormatDatasources(resp, a.Category, budget), 0, nil
+	}
+	return "", 0, fmt.Errorf("unknown tool %q", name)
+}
+
+// reserve sets aside the most a call may spend under the dispatcher's spend
+// limit and every shared budget, and returns it, or 0 if there is no limit.
+// The returned func releases the reservations once the call's actual cost
+// is charged.
+func (d *Dispatcher) reserve() (float64, func(), error) {
+	type held struct {
+		b       *Budget
+		dollars float64
+	}
+	var reserved []held
+	release := func() {
+		for _, h := range reserved {
+			h.b.release(h.dollars)
+		}
+	}
+
+	ceiling := d.cfg.maxCallCost
+	for _, b := range append([]*Budget{d.own}, d.cfg.budgets...) {
+		if b.limit <= 0 {
+			continue
+		}
+		got, err := b.reserve(ceiling)
+		if err != nil {
+			release()
+			return 0, nil, err
+		}
+		reserved = append(reserved, held{b, got})
+		ceiling = got
+	}
+	if len(reserved) == 0 {
+		return 0, release, nil
+	}
+	return ceiling, release, nil
+}
+
+// charge records a cost against the dispatcher and its shared budgets and
+// returns it. A non-empty key is charged at most once.
+func (d *Dispatcher) charge(key string, dollars float64) float64 {
+	d.mu.Lock()
+	if key != "" {
+		if d.charged[key] {
+			d.mu.Unlock()
+			return 0
+		}
+		d.charged[key] = true
+	}
+	d.mu.Unlock()
+	d.own.charge(dollars)
+	for _, b := range d.cfg.budgets {
+		b.charge(dollars)
+	}
+	return dollars
+}
+
+// sources applies the allowlist to the sources a call asked for.
+func (d *Dispatcher) sources(requested []string) ([]string, error) {
+	if len(d.cfg.allowedSources) == 0 {
+		return requested, nil
+	}
+	if len(requested) == 0 {
+		return d.cfg.allowedSources, nil
+	}
+	for _, s := range requested {
+		if !d.sourceAllowed(s) {
+			return nil, fmt.Errorf("source %q is not allowed; allowed sources are %s", s, strings.Join(d.cfg.allowedSources, ", "))
+		}
+	}
+	return requested, nil
+}
+
+func (d *Dispatcher) urlAllowed(raw string) bool {
+	if len(d.cfg.allowedSources) == 0 {
+		return true
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Hostname() == "" {
+		return false
+	}
+	return d.sourceAllowed(u.Hostname() + u.EscapedPath())
+}
+
+// sourceAllowed reports whether s, a source or a URL's host and path, is
+// covered by the allowlist: it equals an allowed source, lies under an
+// allowed source's path, or is on a subdomain of an allowed domain.
+func (d *Dispatcher) sourceAllowed(s string) bool {
+	s = normalizeSource(s)
+	host, _, _ := strings.Cut(s, "/")
+	for _, a := range d.cfg.allowedSources {
+		a = normalizeSource(a)
+		if s == a || strings.HasPrefix(s, a+"/") {
+			return true
+		}
+		if !strings.Contains(a, "/") && strings.HasSuffix(host, "."+a) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeSource lowercases a source and drops any scheme, leading "www."
+// and trailing slash.
+func normalizeSource(s string) string {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if i := strings.Index(s, "://"); i >= 0 {
+		s = s[i+3:]
+	}
+	s = strings.TrimPrefix(s, "www.")
+	return strings.TrimRight(s, "/")
+}
+
+var paramSchemas = func() map[string]*schema.Schema {
+	m := map[string]*schema.Schema{}
+	for _, t := range All() {
+		m[t.Name] = t.Parameters
+	}
+	return m
+}()
+
+func decodeArgs(args json.RawMessage, v interface{}) error {
+	if err := json.Unmarshal(args, v); err != nil {
+		return fmt.Errorf("invalid arguments: %w", err)
+	}
+	return nil
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
@@ -0,0 +1,235 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Veri5ied/valyu-go/valyu"
+)
+
+// fakeValyu serves /search, charging cost(n) for the n-th request and
+// recording each request body.
+type fakeValyu struct {
+	mu    sync.Mutex
+	reqs  []map[string]interface{}
+	cost  func(n int) float64
+	block chan struct{}
+}
+
+func (f *fakeValyu) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req map[string]interface{}
+	json.NewDecoder(r.Body).Decode(&req)
+	f.mu.Lock()
+	n := len(f.reqs)
+	f.reqs = append(f.reqs, req)
+	f.mu.Unlock()
+	if f.block != nil {
+		<-f.block
+	}
+	fmt.Fprintf(w, `{"success":true,"query":"q","results":[],"total_deduction_dollars":%g}`, f.cost(n))
+}
+
+func newTestDispatcher(t *testing.T, f *fakeValyu, opts ...Option) *Dispatcher {
+	t.Helper()
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	client, err := valyu.New("test-key", valyu.WithBaseURL(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewDispatcher(client, opts...)
+}
+
+func TestSpendLimit(t *testing.T) {
+	costs := []float64{0.3, 0.2, 0.1}
+	f := &fakeValyu{cost: func(n int) float64 { return costs[n] }}
+	d := newTestDispatcher(t, f, WithSpendLimit(0.5))
+
+	for i, want := range []struct {
+		maxPrice float64
+		isError  bool
+	}{
+		{25, false}, // 0.5 left, over 20 results per thousand
+		{10, false}, // 0.2 left
+		{0, true},
+	} {
+		res := d.Do(context.Background(), Call{Name: Search, Arguments: json.RawMessage(`{"query":"q"}`)})
+		if res.IsError != want.isError {
+			t.Fatalf("call %d: IsError = %v (%s), want %v", i, res.IsError, res.Content, want.isError)
+		}
+		if want.isError {
+			if !strings.Contains(res.Content, "spend limit") {
+				t.Errorf("call %d: content = %q, want a spend limit error", i, res.Content)
+			}
+			continue
+		}
+		if got := f.reqs[i]["max_price"]; got != want.maxPrice {
+			t.Errorf("call %d: max_price = %v, want %v", i, got, want.maxPrice)
+		}
+	}
+	if len(f.reqs) != 2 {
+		t.Errorf("made %d requests, want 2", len(f.reqs))
+	}
+	if got := d.Spent(); fmt.Sprintf("%.2f", got) != "0.50" {
+		t.Errorf("Spent() = %v, want 0.5", got)
+	}
+}
+
+func TestSpendLimitConcurrent(t *testing.T) {
+	tests := []struct {
+		name        string
+		maxCallCost float64
+		wantError   bool
+	}{
+		{"whole budget reserved", 0, true},
+		{"per call cap", 0.25, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeValyu{cost: func(int) float64 { return 0.1 }, block: make(chan struct{})}
+			d := newTestDispatcher(t, f, WithSpendLimit(0.5), WithMaxCallCost(tt.maxCallCost))
+			call := Call{Name: Search, Arguments: json.RawMessage(`{"query":"q"}`)}
+
+			first := make(chan Result)
+			go func() { first <- d.Do(context.Background(), call) }()
+			for {
+				f.mu.Lock()
+				n := len(f.reqs)
+				f.mu.Unlock()
+				if n == 1 {
+					break
+				}
+				time.Sleep(time.Millisecond)
+			}
+
+			second := make(chan Result)
+			go func() { second <- d.Do(context.Background(), call) }()
+			if tt.wantError {
+				res := <-second
+				if !res.IsError || !strings.Contains(res.Content, "reserved by calls in progress") {
+					t.Errorf("second call = %+v, want a reservation error", res)
+				}
+				close(f.block)
+			} else {
+				close(f.block)
+				if res := <-second; res.IsError {
+					t.Errorf("second call failed: %s", res.Content)
+				}
+			}
+			if res := <-first; res.IsError {
+				t.Errorf("first call failed: %s", res.Content)
+			}
+			d.own.mu.Lock()
+			reserved := d.own.reserved
+			d.own.mu.Unlock()
+			if reserved != 0 {
+				t.Errorf("reserved = %v after all calls returned, want 0", reserved)
+			}
+		})
+	}
+}
+
+func TestAllowedSources(t *testing.T) {
+	d := NewDispatcher(nil, WithAllowedSources("arxiv.org", "www.Nature.com", "valyu/valyu-pubmed", "example.com/docs"))
+	tests := []struct {
+		source string
+		want   bool
+	}{
+		{"arxiv.org", true},
+		{"export.arxiv.org", true},
+		{"https://arxiv.org/abs/1706.03762", true},
+		{"nature.com", true},
+		{"www.nature.com/articles", true},
+		{"valyu/valyu-pubmed", true},
+		{"VALYU/valyu-pubmed", true},
+		{"valyu/valyu-pubmed-extra", false},
+		{"valyu/valyu-arxiv", false},
+		{"example.com/docs/guide", true},
+		{"example.com", false},
+		{"sub.example.com/docs", false},
+		{"notarxiv.org", false},
+		{"arxiv.org.evil.com", false},
+	}
+	for _, tt := range tests {
+		if got := d.sourceAllowed(tt.source); got != tt.want {
+			t.Errorf("sourceAllowed(%q) = %v, want %v", tt.source, got, tt.want)
+		}
+		if _, err := d.sources([]string{tt.source}); (err == nil) != tt.want {
+			t.Errorf("sources(%q) err = %v, want allowed %v", tt.source, err, tt.want)
+		}
+	}
+
+	for _, tt := range []struct {
+		url  string
+		want bool
+	}{
+		{"https://export.arxiv.org/abs/1", true},
+		{"https://www.nature.com/x", true},
+		{"https://example.com/docs/a", true},
+		{"https://example.com/blog", false},
+		{"https://evil.com/arxiv.org", false},
+		{"not a url", false},
+	} {
+		if got := d.urlAllowed(tt.url); got != tt.want {
+			t.Errorf("urlAllowed(%q) = %v, want %v", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestSharedBudget(t *testing.T) {
+	f := &fakeValyu{cost: func(int) float64 { return 0.3 }}
+	srv := httptest.NewServer(f)
+	defer srv.Close()
+	client, err := valyu.New("test-key", valyu.WithBaseURL(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	shared := NewBudget(0.5)
+	newSession := func() *Dispatcher {
+		return NewDispatcher(client, WithSpendLimit(0.3), WithBudget(shared))
+	}
+	call := Call{Name: Search, Arguments: json.RawMessage(`{"query":"q"}`)}
+
+	first, second, third := newSession(), newSession(), newSession()
+	steps := []struct {
+		d         *Dispatcher
+		wantErr   string
+		wantPrice float64
+	}{
+		{first, "", 15},                    // 0.3 of the session's own limit
+		{first, "spend limit of $0.30", 0}, // the session is spent out
+		{second, "", 10},                   // 0.2 left of the shared budget
+		{third, "spend limit of $0.50", 0}, // the shared budget is spent out
+	}
+	for i, step := range steps {
+		res := step.d.Do(context.Background(), call)
+		if step.wantErr != "" {
+			if !res.IsError || !strings.Contains(res.Content, step.wantErr) {
+				t.Errorf("call %d = %q, want an error containing %q", i, res.Content, step.wantErr)
+			}
+			continue
+		}
+		if res.IsError {
+			t.Fatalf("call %d failed: %s", i, res.Content)
+		}
+		if got := f.reqs[len(f.reqs)-1]["max_price"]; got != step.wantPrice {
+			t.Errorf("call %d: max_price = %v, want %v", i, got, step.wantPrice)
+		}
+	}
+	if got := shared.Spent(); fmt.Sprintf("%.2f", got) != "0.60" {
+		t.Errorf("shared.Spent() = %v, want 0.6", got)
+	}
+	if got := first.Spent(); fmt.Sprintf("%.2f", got) != "0.30" {
+		t.Errorf("first.Spent() = %v, want 0.3", got)
+	}
+	if got := third.Spent(); got != 0 {
+		t.Errorf("third.Spent() = %v, want 0", got)
+	}
+}
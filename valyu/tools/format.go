@@ -0,0 +1,267 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Veri5ied/valyu-go/valyu/answer"
+	"github.com/Veri5ied/valyu-go/valyu/contents"
+	"github.com/Veri5ied/valyu-go/valyu/datasources"
+	"github.com/Veri5ied/valyu-go/valyu/deepresearch"
+	"github.com/Veri5ied/valyu-go/valyu/search"
+)
+
+// minItemChars is the least text kept per list item before items are
+// dropped to stay within a budget.
+const minItemChars = 200
+
+// FormatSearch renders search results as compact JSON of at most about
+// budget characters, shortening result content evenly and dropping the
+// least relevant results if needed.
+func FormatSearch(resp *search.Response, budget int) string {
+	items := make([]map[string]interface{}, len(resp.Results))
+	texts := make([]string, len(resp.Results))
+	for i, r := range resp.Results {
+		item := map[string]interface{}{
+			"title":  r.Title,
+			"url":    r.URL,
+			"source": r.Source,
+		}
+		if date := firstNonEmpty(r.PublicationDate, r.Date); date != "" {
+			item["date"] = date
+		}
+		if r.RelevanceScore > 0 {
+			item["relevance"] = round(r.RelevanceScore)
+		}
+		items[i] = item
+		texts[i] = firstNonEmpty(text(r.Content), r.Description)
+	}
+	return fitList(map[string]interface{}{"query": resp.Query}, "results", items, "content", texts, budget)
+}
+
+// FormatContents renders extracted pages as compact JSON of at most about
+// budget characters. Summaries are used in place of full text when present.
+func FormatContents(resp *contents.Response, budget int) string {
+	items := make([]map[string]interface{}, len(resp.Results))
+	texts := make([]string, len(resp.Results))
+	for i, r := range resp.Results {
+		items[i] = map[string]interface{}{
+			"title": r.Title,
+			"url":   r.URL,
+		}
+		texts[i] = firstNonEmpty(text(r.Summary), text(r.Content))
+	}
+	return fitList(map[string]interface{}{}, "results", items, "content", texts, budget)
+}
+
+// FormatAnswer renders an answer and the sources it cites as compact JSON of
+// at most about budget characters. Source numbers match the [n] markers in
+// the answer.
+func FormatAnswer(resp *answer.Response, budget int) string {
+	cited := resp.Citations().Cited()
+	if len(cited) == 0 {
+		for i := range resp.SearchResults {
+			cited = append(cited, i)
+		}
+	}
+	sort.Ints(cited)
+
+	var sources []map[string]interface{}
+	for _, i := range cited {
+		r := resp.SearchResults[i]
+		sources = append(sources, map[string]interface{}{"n": i + 1, "title": r.Title, "url": r.URL})
+	}
+	out := map[string]interface{}{"sources": sources}
+	return fitText(out, "answer", text(resp.Contents), budget)
+}
+
+// FormatDeepResearch renders the status of a research task, including its
+// output once completed, as compact JSON of at most about budget characters.
+func FormatDeepResearch(resp *deepresearch.StatusResponse, budget int) string {
+	out := map[string]interface{}{
+		"deepresearch_id": resp.DeepResearchID,
+		"status":          resp.Status,
+	}
+	if resp.Progress != nil {
+		out["progress"] = fmt.Sprintf("%d/%d", resp.Progress.CurrentStep, resp.Progress.TotalSteps)
+	}
+	if resp.PDFURL != "" {
+		out["pdf_url"] = resp.PDFURL
+	}
+	if resp.Error != "" {
+		out["error"] = resp.Error
+	}
+	var sources []map[string]interface{}
+	for _, s := range resp.Sources {
+		sources = append(sources, map[string]interface{}{"title": s.Title, "url": s.URL})
+	}
+	if len(sources) > 0 {
+		out["sources"] = sources
+	}
+	return fitText(out, "output", text(resp.Output), budget)
+}
+
+// FormatDatasources renders the datasets, optionally limited to one
+// category, as compact JSON of at most about budget characters.
+func FormatDatasources(resp *datasources.ListResponse, category string, budget int) string {
+	var items []map[string]interface{}
+	var texts []string
+	for _, ds := range resp.Datasources {
+		if category != "" && string(ds.Category) != category {
+			continue
+		}
+		items = append(items, map[string]interface{}{
+			"id":       ds.ID,
+			"name":     ds.Name,
+			"category": ds.Category,
+			"cpm":      ds.Pricing.CPM,
+		})
+		texts = append(texts, ds.Description)
+	}
+	return fitList(map[string]interface{}{}, "datasources", items, "description", texts, budget)
+}
+
+// fitText sets key to as much of s as fits in budget alongside out. If the
+// rest of out alone is over budget, list values are shortened first.
+func fitText(out map[string]interface{}, key, s string, budget int) string {
+	out[key] = ""
+	for {
+		room := budget - len(compactJSON(out))
+		if room >= 0 || !shrinkLists(out) {
+			out[key] = truncate(s, room)
+			return compactJSON(out)
+		}
+	}
+}
+
+// shrinkLists drops the last element of the longest list in out.
+func shrinkLists(out map[string]interface{}) bool {
+	var longest string
+	n := 0
+	for k, v := range out {
+		if l, ok := v.([]map[string]interface{}); ok && len(l) > n {
+			longest, n = k, len(l)
+		}
+	}
+	if n == 0 {
+		return false
+	}
+	out[longest] = out[longest].([]map[string]interface{})[:n-1]
+	return true
+}
+
+// fitList puts items under key in head, giving each the matching text under
+// textKey. Texts share what is left of the budget; items are dropped from
+// the end while each would get fewer than minItemChars.
+func fitList(head map[string]interface{}, key string, items []map[string]interface{}, textKey string, texts []string, budget int) string {
+	for n := len(items); ; n-- {
+		for _, item := range items[:n] {
+			item[textKey] = ""
+		}
+		head[key] = items[:n]
+		if n < len(items) {
+			head["omitted"] = len(items) - n
+		}
+		room := budget - len(compactJSON(head))
+		if n > 1 && room < n*minItemChars {
+			continue
+		}
+		for i, limit := range allocate(texts[:n], room) {
+			items[i][textKey] = truncate(texts[i], limit)
+		}
+		return compactJSON(head)
+	}
+}
+
+// allocate splits total characters between texts so that short texts keep
+// all of theirs and long ones share the rest equally.
+func allocate(texts []string, total int) []int {
+	limits := make([]int, len(texts))
+	order := make([]int, len(texts))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(a, b int) bool { return len(texts[order[a]]) < len(texts[order[b]]) })
+	for k, i := range order {
+		share := total / (len(texts) - k)
+		if share < 0 {
+			share = 0
+		}
+		if l := escapedLen(texts[i]); l <= share {
+			limits[i] = l
+			total -= l
+		} else {
+			limits[i] = share
+			total -= share
+		}
+	}
+	return limits
+}
+
+// escapedLen is the length of s once encoded as a JSON string, without
+// quotes.
+func escapedLen(s string) int {
+	return len(compactJSON(s)) - 2
+}
+
+// truncate shortens s so its JSON encoding stays within n bytes, cutting at a
+// word boundary where possible.
+func truncate(s string, n int) string {
+	if escapedLen(s) <= n {
+		return s
+	}
+	const ellipsis = "…"
+	n -= len(ellipsis)
+	if n <= 0 {
+		return ""
+	}
+	hi := n
+	if hi > len(s) {
+		hi = len(s)
+	}
+	// Find the longest prefix whose encoding fits.
+	cut := sort.Search(hi+1, func(i int) bool { return escapedLen(s[:i]) > n }) - 1
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	if i := strings.LastIndexAny(s[:cut], " \n\t"); i > cut*3/4 {
+		cut = i
+	}
+	return strings.TrimSpace(s[:cut]) + ellipsis
+}
+
+func compactJSON(v interface{}) string {
+	var b strings.Builder
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+func text(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	}
+	return compactJSON(v)
+}
+
+func firstNonEmpty(ss ...string) string {
+	for _, s := range ss {
+		if s != "" {
+			return s
+		}
+	}
+	return ""
+}
+
+func round(f float64) float64 {
+	return float64(int(f*1000+0.5)) / 1000
+}
@@ -0,0 +1,165 @@
+package tools
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/Veri5ied/valyu-go/valyu/answer"
+	"github.com/Veri5ied/valyu-go/valyu/contents"
+	"github.com/Veri5ied/valyu-go/valyu/search"
+)
+
+func searchResponse(n int, content string) *search.Response {
+	resp := &search.Response{Query: "q"}
+	for i := 0; i < n; i++ {
+		resp.Results = append(resp.Results, search.Result{
+			Title:   "Result",
+			URL:     "https://example.com/" + strings.Repeat("x", i),
+			Source:  "web",
+			Content: content,
+		})
+	}
+	return resp
+}
+
+func TestFormatSearchBudget(t *testing.T) {
+	long := strings.Repeat("lorem ipsum dolor sit amet ", 400)
+	tests := []struct {
+		name        string
+		resp        *search.Response
+		budget      int
+		wantResults int
+		wantOmitted int
+		wantFull    bool
+	}{
+		{"fits whole", searchResponse(3, "short text"), 8000, 3, 0, true},
+		{"shortens evenly", searchResponse(3, long), 8000, 3, 0, false},
+		{"drops results", searchResponse(10, long), 1500, 5, 5, false},
+		{"keeps one result", searchResponse(5, long), 100, 1, 4, false},
+		{"no results", searchResponse(0, ""), 100, 0, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := FormatSearch(tt.resp, tt.budget)
+			var got struct {
+				Results []struct {
+					Content string `json:"content"`
+				} `json:"results"`
+				Omitted int `json:"omitted"`
+			}
+			if err := json.Unmarshal([]byte(out), &got); err != nil {
+				t.Fatalf("invalid JSON %q: %v", out, err)
+			}
+			if len(got.Results) > 1 && len(out) > tt.budget {
+				t.Errorf("len = %d, over budget %d", len(out), tt.budget)
+			}
+			if len(got.Results) != tt.wantResults || got.Omitted != tt.wantOmitted {
+				t.Errorf("results = %d, omitted = %d; want %d, %d", len(got.Results), got.Omitted, tt.wantResults, tt.wantOmitted)
+			}
+			for i, r := range got.Results {
+				full := r.Content == tt.resp.Results[i].Content
+				if full != tt.wantFull {
+					t.Errorf("result %d: content kept whole = %v, want %v", i, full, tt.wantFull)
+				}
+				if !full && !strings.HasSuffix(r.Content, "…") && r.Content != "" {
+					t.Errorf("result %d: shortened content %q has no ellipsis", i, r.Content)
+				}
+			}
+		})
+	}
+}
+
+func TestFormatContentsPrefersSummary(t *testing.T) {
+	resp := &contents.Response{Results: []contents.Result{
+		{Title: "a", URL: "https://a.com", Content: "full text", Summary: "summary"},
+		{Title: "b", URL: "https://b.com", Content: "only text"},
+	}}
+	out := FormatContents(resp, 1000)
+	if !strings.Contains(out, `"content":"summary"`) || !strings.Contains(out, `"content":"only text"`) {
+		t.Errorf("FormatContents = %s", out)
+	}
+}
+
+func TestFormatAnswerBudget(t *testing.T) {
+	resp := &answer.Response{
+		Contents: strings.Repeat("An answer sentence [1]. ", 200),
+		SearchResults: []search.Result{
+			{Title: "one", URL: "https://one.com"},
+			{Title: "two", URL: "https://two.com"},
+		},
+	}
+	for _, budget := range []int{200, 1000, 10000} {
+		out := FormatAnswer(resp, budget)
+		var got struct {
+			Answer  string `json:"answer"`
+			Sources []struct {
+				N int `json:"n"`
+			} `json:"sources"`
+		}
+		if err := json.Unmarshal([]byte(out), &got); err != nil {
+			t.Fatalf("budget %d: invalid JSON %q: %v", budget, out, err)
+		}
+		if len(out) > budget {
+			t.Errorf("budget %d: len = %d", budget, len(out))
+		}
+		if len(got.Sources) != 1 || got.Sources[0].N != 1 {
+			t.Errorf("budget %d: sources = %+v, want only the cited [1]", budget, got.Sources)
+		}
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		n    int
+		want string
+	}{
+		{"fits", "hello world", 20, "hello world"},
+		{"word boundary", "hello wonderful world", 18, "hello wonderful…"},
+		{"no room", "hello", 2, ""},
+		{"escapes count", `"quoted"`, 9, `"quot…`},
+		{"multibyte", "héllo wörld", 9, "héllo…"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := truncate(tt.s, tt.n)
+			if got != tt.want {
+				t.Errorf("truncate(%q, %d) = %q, want %q", tt.s, tt.n, got, tt.want)
+			}
+			if !utf8.ValidString(got) {
+				t.Errorf("truncate(%q, %d) = %q is not valid UTF-8", tt.s, tt.n, got)
+			}
+			if escapedLen(got) > tt.n && got != tt.s {
+				t.Errorf("truncate(%q, %d) = %q encodes to %d bytes", tt.s, tt.n, got, escapedLen(got))
+			}
+		})
+	}
+}
+
+func TestAllocate(t *testing.T) {
+	tests := []struct {
+		name  string
+		texts []string
+		total int
+		want  []int
+	}{
+		{"all fit", []string{"ab", "cde"}, 10, []int{2, 3}},
+		{"short keeps all", []string{strings.Repeat("x", 100), "ab", strings.Repeat("y", 100)}, 42, []int{20, 2, 20}},
+		{"equal share", []string{strings.Repeat("x", 50), strings.Repeat("y", 50)}, 30, []int{15, 15}},
+		{"negative", []string{"ab"}, -5, []int{0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := allocate(tt.texts, tt.total)
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("allocate = %v, want %v", got, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
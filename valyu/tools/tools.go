@@ -0,0 +1,158 @@
+// Package tools exposes Valyu services as function-calling tools for LLM
+// agents. Definitions are generated from the argument structs below and can
+// be sent in OpenAI or Anthropic format; a Dispatcher runs the calls the
+// model makes and returns compact results sized to a token budget:
+//
+//	d := tools.NewDispatcher(client)
+//	req.Tools = tools.OpenAI(d.Tools())
+//	...
+//	for _, tc := range msg.ToolCalls {
+//		res, err := d.Dispatch(ctx, tc)
+//		...
+//	}
+package tools
+
+import (
+	"reflect"
+
+	"github.com/Veri5ied/valyu-go/valyu/schema"
+)
+
+// Tool names.
+const (
+	Search       = "valyu_search"
+	Contents     = "valyu_contents"
+	Answer       = "valyu_answer"
+	DeepResearch = "valyu_deepresearch"
+	Datasources  = "valyu_datasources"
+)
+
+// SearchArgs are the arguments of the search tool.
+type SearchArgs struct {
+	Query              string   `json:"query" jsonschema:"description=What to search for"`
+	SearchType         string   `json:"search_type,omitempty" jsonschema:"description=Which index to search,enum=all,enum=web,enum=proprietary,enum=news"`
+	MaxNumResults      int      `json:"max_num_results,omitempty" jsonschema:"description=Maximum number of results (1-20)"`
+	IncludedSources    []string `json:"included_sources,omitempty" jsonschema:"description=Only search these sources (domains or dataset IDs such as valyu/valyu-arxiv)"`
+	ExcludedSources    []string `json:"excluded_sources,omitempty" jsonschema:"description=Never return results from these sources"`
+	StartDate          string   `json:"start_date,omitempty" jsonschema:"description=Earliest publication date (YYYY-MM-DD),format=date"`
+	EndDate            string   `json:"end_date,omitempty" jsonschema:"description=Latest publication date (YYYY-MM-DD),format=date"`
+	RelevanceThreshold float64  `json:"relevance_threshold,omitempty" jsonschema:"description=Minimum relevance score between 0 and 1"`
+}
+
+// ContentsArgs are the arguments of the contents tool.
+type ContentsArgs struct {
+	URLs    []string `json:"urls" jsonschema:"description=Pages to extract (at most 10)"`
+	Summary string   `json:"summary,omitempty" jsonschema:"description=Optional instructions for summarising each page instead of returning its full text"`
+}
+
+// AnswerArgs are the arguments of the answer tool.
+type AnswerArgs struct {
+	Query           string   `json:"query" jsonschema:"description=The question to answer from search results"`
+	SearchType      string   `json:"search_type,omitempty" jsonschema:"description=Which index to search,enum=all,enum=web,enum=proprietary,enum=news"`
+	IncludedSources []string `json:"included_sources,omitempty" jsonschema:"description=Only use these sources"`
+	StartDate       string   `json:"start_date,omitempty" jsonschema:"description=Earliest publication date (YYYY-MM-DD),format=date"`
+	EndDate         string   `json:"end_date,omitempty" jsonschema:"description=Latest publication date (YYYY-MM-DD),format=date"`
+}
+
+// DeepResearchArgs are the arguments of the deep research tool. A call with
+// a query starts a task; a call with its deepresearch_id reports its status
+// and, once finished, its output.
+type DeepResearchArgs struct {
+	Query          string `json:"query,omitempty" jsonschema:"description=Research question for a new task"`
+	Mode           string `json:"mode,omitempty" jsonschema:"description=Depth of research,enum=fast,enum=standard,enum=heavy"`
+	DeepResearchID string `json:"deepresearch_id,omitempty" jsonschema:"description=ID of an existing task to check instead of starting one"`
+}
+
+// DatasourcesArgs are the arguments of the datasources tool.
+type DatasourcesArgs struct {
+	Category string `json:"category,omitempty" jsonschema:"description=Only list datasets in this category,enum=research,enum=healthcare,enum=patents,enum=markets,enum=company,enum=economic,enum=predictions,enum=transportation,enum=legal,enum=politics"`
+}
+
+// Tool is a provider-neutral tool definition.
+type Tool struct {
+	Name        string
+	Description string
+	Parameters  *schema.Schema
+}
+
+var definitions = []struct {
+	name, description string
+	args              interface{}
+}{
+	{Search, "Search the web and Valyu's proprietary datasets (academic papers, financial data, news and more). Returns titles, URLs and content excerpts.", SearchArgs{}},
+	{Contents, "Extract the readable content of web pages, optionally summarised.", ContentsArgs{}},
+	{Answer, "Answer a question with an AI-written response grounded in fresh search results, with cited sources.", AnswerArgs{}},
+	{DeepResearch, "Run a long multi-step research task that produces a report. Start it with a query, then check on it with the returned deepresearch_id; tasks take minutes.", DeepResearchArgs{}},
+	{Datasources, "List the proprietary datasets that can be passed as included_sources, with what they cover and their price.", DatasourcesArgs{}},
+}
+
+// All returns the definitions of every tool.
+func All() []Tool {
+	out := make([]Tool, 0, len(definitions))
+	for _, def := range definitions {
+		out = append(out, Tool{
+			Name:        def.name,
+			Description: def.description,
+			Parameters:  parameters(def.args),
+		})
+	}
+	return out
+}
+
+func parameters(args interface{}) *schema.Schema {
+	s, err := schema.Generate(reflect.TypeOf(args))
+	if err != nil {
+		panic("tools: " + err.Error())
+	}
+	// Providers expect a bare object schema.
+	s.Schema = ""
+	return s
+}
+
+// OpenAITool is a tool in the OpenAI chat completions format.
+type OpenAITool struct {
+	Type     string         `json:"type"`
+	Function OpenAIFunction `json:"function"`
+}
+
+type OpenAIFunction struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Parameters  *schema.Schema `json:"parameters"`
+}
+
+// OpenAI converts tools to the OpenAI format.
+func OpenAI(tools []Tool) []OpenAITool {
+	out := make([]OpenAITool, len(tools))
+	for i, t := range tools {
+		out[i] = OpenAITool{
+			Type: "function",
+			Function: OpenAIFunction{
+				Name:        t.Name,
+				Description: t.Description,
+				Parameters:  t.Parameters,
+			},
+		}
+	}
+	return out
+}
+
+// AnthropicTool is a tool in the Anthropic messages format.
+type AnthropicTool struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	InputSchema *schema.Schema `json:"input_schema"`
+}
+
+// Anthropic converts tools to the Anthropic format.
+func Anthropic(tools []Tool) []AnthropicTool {
+	out := make([]AnthropicTool, len(tools))
+	for i, t := range tools {
+		out[i] = AnthropicTool{
+			Name:        t.Name,
+			Description: t.Description,
+			InputSchema: t.Parameters,
+		}
+	}
+	return out
+}
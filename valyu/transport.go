@@ -0,0 +1,119 @@
+package valyu
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+type transportConfig struct {
+	proxyURL        string
+	tlsConfig       *tls.Config
+	caBundle        string
+	maxIdleConns    int
+	maxIdlePerHost  int
+	http2           *bool
+	dialTimeout     time.Duration
+	responseTimeout time.Duration
+}
+
+func (t transportConfig) isSet() bool {
+	return t.proxyURL != "" || t.tlsConfig != nil || t.caBundle != "" ||
+		t.maxIdleConns > 0 || t.maxIdlePerHost > 0 || t.http2 != nil ||
+		t.dialTimeout > 0 || t.responseTimeout > 0
+}
+
+// buildHTTPClient resolves the HTTP options after all of them have been
+// applied, so their order does not matter. A client passed to WithHTTPClient
+// is copied rather than modified.
+func (c *Client) buildHTTPClient() (*http.Client, error) {
+	var hc http.Client
+	if c.httpClient != nil {
+		hc = *c.httpClient
+	} else {
+		hc.Timeout = DefaultTimeout
+	}
+	if c.timeout != nil {
+		hc.Timeout = *c.timeout
+	}
+
+	if !c.transport.isSet() {
+		return &hc, nil
+	}
+
+	var base *http.Transport
+	switch t := hc.Transport.(type) {
+	case nil:
+		base = http.DefaultTransport.(*http.Transport)
+	case *http.Transport:
+		base = t
+	default:
+		return nil, fmt.Errorf("transport options require an *http.Transport, got %T", hc.Transport)
+	}
+
+	tr, err := c.transport.apply(base.Clone())
+	if err != nil {
+		return nil, err
+	}
+	hc.Transport = tr
+	return &hc, nil
+}
+
+func (t transportConfig) apply(tr *http.Transport) (*http.Transport, error) {
+	if t.proxyURL != "" {
+		u, err := url.Parse(t.proxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL: %w", err)
+		}
+		tr.Proxy = http.ProxyURL(u)
+	}
+
+	if t.tlsConfig != nil {
+		tr.TLSClientConfig = t.tlsConfig.Clone()
+	}
+	if t.caBundle != "" {
+		pem, err := os.ReadFile(t.caBundle)
+		if err != nil {
+			return nil, fmt.Errorf("read CA bundle: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA bundle %s", t.caBundle)
+		}
+		if tr.TLSClientConfig == nil {
+			tr.TLSClientConfig = &tls.Config{}
+		}
+		tr.TLSClientConfig.RootCAs = pool
+	}
+
+	if t.maxIdleConns > 0 {
+		tr.MaxIdleConns = t.maxIdleConns
+	}
+	if t.maxIdlePerHost > 0 {
+		tr.MaxIdleConnsPerHost = t.maxIdlePerHost
+	}
+
+	if t.dialTimeout > 0 {
+		dialer := &net.Dialer{Timeout: t.dialTimeout, KeepAlive: 30 * time.Second}
+		tr.DialContext = dialer.DialContext
+		tr.TLSHandshakeTimeout = t.dialTimeout
+	}
+	if t.responseTimeout > 0 {
+		tr.ResponseHeaderTimeout = t.responseTimeout
+	}
+
+	if t.http2 != nil {
+		if *t.http2 {
+			tr.ForceAttemptHTTP2 = true
+		} else {
+			tr.ForceAttemptHTTP2 = false
+			tr.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+		}
+	}
+	return tr, nil
+}
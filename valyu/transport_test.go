@@ -0,0 +1,72 @@
+package valyu
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestBuildHTTPClientCopiesCallerClient(t *testing.T) {
+	tr := &http.Transport{MaxIdleConns: 7}
+	hc := &http.Client{Timeout: time.Minute, Transport: tr}
+
+	c, err := New("key", WithTimeout(5*time.Second), WithHTTPClient(hc), WithHTTP2(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if c.httpClient == hc {
+		t.Fatal("client reused the caller's *http.Client")
+	}
+	if c.httpClient.Timeout != 5*time.Second {
+		t.Errorf("Timeout = %v, want 5s regardless of option order", c.httpClient.Timeout)
+	}
+	got, ok := c.httpClient.Transport.(*http.Transport)
+	if !ok || got == tr {
+		t.Fatalf("Transport = %T %p, want a clone of the caller's transport", c.httpClient.Transport, c.httpClient.Transport)
+	}
+	if got.MaxIdleConns != 7 || got.TLSNextProto == nil || len(got.TLSNextProto) != 0 {
+		t.Errorf("cloned transport = MaxIdleConns %d, TLSNextProto %v; want 7 and HTTP/2 disabled", got.MaxIdleConns, got.TLSNextProto)
+	}
+
+	if hc.Timeout != time.Minute || hc.Transport != tr {
+		t.Errorf("caller's client was modified: Timeout %v, Transport %p", hc.Timeout, hc.Transport)
+	}
+	// Clone fills in the original's HTTP/2 defaults, so only compare the
+	// fields the options set.
+	if tr.MaxIdleConns != 7 || tr.ForceAttemptHTTP2 || len(tr.TLSNextProto) == 0 {
+		t.Errorf("caller's transport was modified: MaxIdleConns %d, ForceAttemptHTTP2 %v, TLSNextProto %v",
+			tr.MaxIdleConns, tr.ForceAttemptHTTP2, tr.TLSNextProto)
+	}
+}
+
+func TestBuildHTTPClientOptionOrder(t *testing.T) {
+	hc := &http.Client{Timeout: time.Minute}
+	for name, opts := range map[string][]Option{
+		"timeout first": {WithTimeout(5 * time.Second), WithHTTPClient(hc)},
+		"client first":  {WithHTTPClient(hc), WithTimeout(5 * time.Second)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			c, err := New("key", opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.httpClient.Timeout != 5*time.Second {
+				t.Errorf("Timeout = %v, want 5s", c.httpClient.Timeout)
+			}
+			if hc.Timeout != time.Minute {
+				t.Errorf("caller's Timeout changed to %v", hc.Timeout)
+			}
+		})
+	}
+}
+
+func TestBuildHTTPClientDefaults(t *testing.T) {
+	c, err := New("key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.httpClient.Timeout != DefaultTimeout || c.httpClient.Transport != nil {
+		t.Errorf("default client = Timeout %v, Transport %T; want %v and the default transport", c.httpClient.Timeout, c.httpClient.Transport, DefaultTimeout)
+	}
+}